@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// api responde às rotas JSON em /api/v1/
+type api struct {
+	*dadosWeb
+	rotas *http.ServeMux
+}
+
+func novaAPI(dados *dadosWeb) *api {
+	a := &api{dadosWeb: dados, rotas: http.NewServeMux()}
+	a.rotas.Handle("/api/v1/busca", a.cacheável(a.busca))
+	a.rotas.Handle("/api/v1/caractere/", a.cacheável(a.caractere))
+	a.rotas.Handle("/api/v1/blocos", a.cacheável(a.listarBlocos))
+	a.rotas.Handle("/api/v1/descrever", a.cacheável(a.descrever))
+	a.rotas.Handle("/api/v1/sugerir", a.cacheável(a.sugerir))
+	a.rotas.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		responderErro(w, http.StatusNotFound, "rota desconhecida: %s", r.URL.Path)
+	})
+	return a
+}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		responderErro(w, http.StatusMethodNotAllowed, "método não permitido: %s", r.Method)
+		return
+	}
+	a.rotas.ServeHTTP(w, r)
+}
+
+// erroAPI é o corpo das respostas de erro
+type erroAPI struct {
+	Status int    `json:"status"`
+	Erro   string `json:"erro"`
+}
+
+func responderJSON(w http.ResponseWriter, status int, valor interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(valor)
+}
+
+func responderErro(w http.ResponseWriter, status int, formato string, args ...interface{}) {
+	responderJSON(w, status, erroAPI{Status: status, Erro: fmt.Sprintf(formato, args...)})
+}
+
+type respostaBusca struct {
+	Consulta string `json:"consulta"`
+	paginação
+	Resultados []registro `json:"resultados"`
+}
+
+func (a *api) busca(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		responderErro(w, http.StatusBadRequest, "informe a consulta no parâmetro q")
+		return
+	}
+	consulta := ExpandirSinônimos(strings.ToUpper(q), a.sinônimos)
+	índices, err := a.filtrar(r.Context(), consulta)
+	if err != nil {
+		status, mensagem := erroDeConsulta(err)
+		responderErro(w, status, "%s", mensagem)
+		return
+	}
+	anotarResultados(w, len(índices))
+	p, err := paginar(r, len(índices), a.páginaMáxima)
+	if err != nil {
+		responderErro(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	resposta := respostaBusca{Consulta: consulta, paginação: p, Resultados: []registro{}}
+	início, fim := p.intervalo()
+	for _, c := range caracteresDe(a.linhas, índices[início:fim]) {
+		resposta.Resultados = append(resposta.Resultados, novoRegistro(c))
+	}
+	responderJSON(w, http.StatusOK, resposta)
+}
+
+type respostaCaractere struct {
+	registro
+	Bloco string `json:"bloco,omitempty"`
+}
+
+func (a *api) caractere(w http.ResponseWriter, r *http.Request) {
+	runa, err := analisarCódigo(strings.TrimPrefix(r.URL.Path, "/api/v1/caractere/"))
+	if err != nil {
+		responderErro(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	c, ok := buscarCaractere(a.linhas, runa)
+	if !ok {
+		responderErro(w, http.StatusNotFound, "U+%04X não encontrado", runa)
+		return
+	}
+	responderJSON(w, http.StatusOK, respostaCaractere{novoRegistro(c), blocoDe(a.blocos, runa)})
+}
+
+type registroBloco struct {
+	Início string `json:"inicio"`
+	Fim    string `json:"fim"`
+	Nome   string `json:"nome"`
+}
+
+func (a *api) listarBlocos(w http.ResponseWriter, r *http.Request) {
+	if a.blocos == nil {
+		responderErro(w, http.StatusServiceUnavailable, "Blocks.txt não disponível no servidor")
+		return
+	}
+	registros := make([]registroBloco, len(a.blocos))
+	for i, b := range a.blocos {
+		registros[i] = registroBloco{fmt.Sprintf("U+%04X", b.Início), fmt.Sprintf("U+%04X", b.Fim), b.Nome}
+	}
+	responderJSON(w, http.StatusOK, map[string][]registroBloco{"blocos": registros})
+}
+
+type respostaDescrever struct {
+	Texto      string     `json:"texto"`
+	Caracteres []registro `json:"caracteres"`
+}
+
+func (a *api) descrever(w http.ResponseWriter, r *http.Request) {
+	texto := r.URL.Query().Get("texto")
+	if texto == "" {
+		responderErro(w, http.StatusBadRequest, "informe o texto no parâmetro texto")
+		return
+	}
+	resposta := respostaDescrever{Texto: texto, Caracteres: []registro{}}
+	for _, runa := range texto {
+		c, ok := buscarCaractere(a.linhas, runa)
+		if !ok {
+			c = Caractere{Runa: runa}
+		}
+		resposta.Caracteres = append(resposta.Caracteres, novoRegistro(c))
+	}
+	responderJSON(w, http.StatusOK, resposta)
+}
+
+type respostaSugerir struct {
+	Prefixo  string     `json:"prefixo"`
+	Palavras []string   `json:"palavras"`
+	Nomes    []registro `json:"nomes"`
+}
+
+func (a *api) sugerir(w http.ResponseWriter, r *http.Request) {
+	if a.sugestões == nil {
+		responderErro(w, http.StatusServiceUnavailable, "sugestões não disponíveis no servidor")
+		return
+	}
+	prefixo := r.URL.Query().Get("prefixo")
+	if strings.TrimSpace(prefixo) == "" {
+		responderErro(w, http.StatusBadRequest, "informe o início da consulta no parâmetro prefixo")
+		return
+	}
+	limite, err := lerInteiro(r, "limite", sugestõesPadrão, 1)
+	if err != nil {
+		responderErro(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if limite > máximoSugestões {
+		limite = máximoSugestões
+	}
+	palavras, nomes := a.sugestões.sugerir(prefixo, limite)
+	anotarResultados(w, len(palavras)+len(nomes))
+	if r.URL.Query().Get("formato") == "opensearch" {
+		responderSugestõesOpenSearch(w, prefixo, palavras, nomes)
+		return
+	}
+	resposta := respostaSugerir{Prefixo: prefixo, Palavras: palavras, Nomes: []registro{}}
+	for _, c := range nomes {
+		resposta.Nomes = append(resposta.Nomes, novoRegistro(c))
+	}
+	responderJSON(w, http.StatusOK, resposta)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func novaAPIDeTeste(t *testing.T) *api {
+	t.Helper()
+	blocos, err := carregarBlocos(strings.NewReader(blocosDeTeste))
+	if err != nil {
+		t.Fatal(err)
+	}
+	linhas := carregar(strings.NewReader(linhas3Da43))
+	return novaAPI(&dadosWeb{linhas: linhas, blocos: blocos, sinônimos: map[string]string{"MAIOR": "GREATER"},
+		páginaMáxima: páginaMáximaPadrão, sugestões: novoSugestor(linhas)})
+}
+
+// requisitar faz a requisição à API e decodifica o corpo JSON
+func requisitar(t *testing.T, a http.Handler, método, alvo string) (int, map[string]interface{}) {
+	t.Helper()
+	gravador := httptest.NewRecorder()
+	a.ServeHTTP(gravador, httptest.NewRequest(método, alvo, nil))
+	if tipo := gravador.Header().Get("Content-Type"); !strings.HasPrefix(tipo, "application/json") {
+		t.Errorf("%s %s: Content-Type %q", método, alvo, tipo)
+	}
+	corpo := map[string]interface{}{}
+	if err := json.Unmarshal(gravador.Body.Bytes(), &corpo); err != nil {
+		t.Errorf("%s %s: JSON inválido: %v\n%s", método, alvo, err, gravador.Body.String())
+	}
+	return gravador.Code, corpo
+}
+
+func TestAPI_status(t *testing.T) {
+	a := novaAPIDeTeste(t)
+	casos := []struct {
+		método string
+		alvo   string
+		status int
+	}{
+		{"GET", "/api/v1/busca?q=sign", http.StatusOK},
+		{"GET", "/api/v1/busca", http.StatusBadRequest},
+		{"GET", "/api/v1/busca?q=-", http.StatusBadRequest},
+		{"GET", "/api/v1/caractere/U+0041", http.StatusOK},
+		{"GET", "/api/v1/caractere/xyz", http.StatusBadRequest},
+		{"GET", "/api/v1/caractere/2603", http.StatusNotFound},
+		{"GET", "/api/v1/blocos", http.StatusOK},
+		{"GET", "/api/v1/descrever?texto=A%E2%98%83", http.StatusOK},
+		{"GET", "/api/v1/descrever", http.StatusBadRequest},
+		{"GET", "/api/v1/sugerir?prefixo=gr", http.StatusOK},
+		{"GET", "/api/v1/sugerir", http.StatusBadRequest},
+		{"GET", "/api/v1/sugerir?prefixo=a&limite=0", http.StatusBadRequest},
+		{"GET", "/api/v1/desconhecida", http.StatusNotFound},
+		{"GET", "/api/v2/busca?q=a", http.StatusNotFound},
+		{"POST", "/api/v1/busca?q=a", http.StatusMethodNotAllowed},
+	}
+	for _, caso := range casos {
+		status, corpo := requisitar(t, a, caso.método, caso.alvo)
+		if status != caso.status {
+			t.Errorf("%s %s\nesperado: %d; recebido: %d %v", caso.método, caso.alvo, caso.status, status, corpo)
+		}
+		if status >= 400 && (corpo["erro"] == nil || corpo["status"] != float64(status)) {
+			t.Errorf("%s %s: corpo de erro sem os campos erro e status: %v", caso.método, caso.alvo, corpo)
+		}
+	}
+}
+
+func TestAPI_busca(t *testing.T) {
+	_, corpo := requisitar(t, novaAPIDeTeste(t), "GET", "/api/v1/busca?q=maior+sign")
+	if corpo["consulta"] != "GREATER SIGN" || corpo["total"] != float64(1) {
+		t.Fatalf("busca com sinônimo: %v", corpo)
+	}
+	resultado := corpo["resultados"].([]interface{})[0].(map[string]interface{})
+	if resultado["codigo"] != "U+003E" || resultado["caractere"] != ">" || resultado["categoria"] != "Sm" {
+		t.Errorf("resultado: %v", resultado)
+	}
+}
+
+func TestAPI_sugerir(t *testing.T) {
+	_, corpo := requisitar(t, novaAPIDeTeste(t), "GET", "/api/v1/sugerir?prefixo=latin+capital+l&limite=2")
+	if palavras := corpo["palavras"].([]interface{}); len(palavras) != 2 || palavras[1] != "LETTER" {
+		t.Errorf("palavras: %v", corpo)
+	}
+	nomes := corpo["nomes"].([]interface{})
+	if len(nomes) != 2 || nomes[0].(map[string]interface{})["codigo"] != "U+0041" {
+		t.Errorf("nomes: %v", corpo)
+	}
+}
+
+func TestAPI_caractere(t *testing.T) {
+	_, corpo := requisitar(t, novaAPIDeTeste(t), "GET", "/api/v1/caractere/A")
+	if corpo["nome"] != "LATIN CAPITAL LETTER A" || corpo["bloco"] != "Basic Latin" || corpo["minuscula"] != "U+0061" {
+		t.Errorf("caractere: %v", corpo)
+	}
+}
+
+func TestAPI_blocosIndisponíveis(t *testing.T) {
+	a := novaAPI(&dadosWeb{páginaMáxima: páginaMáximaPadrão})
+	if status, _ := requisitar(t, a, "GET", "/api/v1/blocos"); status != http.StatusServiceUnavailable {
+		t.Errorf("sem Blocks.txt\nesperado: %d; recebido: %d", http.StatusServiceUnavailable, status)
+	}
+}
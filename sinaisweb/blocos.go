@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bloco é uma faixa de códigos com nome, como "0000..007F; Basic Latin"
+// no Blocks.txt
+type bloco struct {
+	Início rune
+	Fim    rune
+	Nome   string
+}
+
+// carregarBlocos lê as linhas do Blocks.txt, ignorando comentários
+func carregarBlocos(arquivo io.Reader) ([]bloco, error) {
+	blocos := []bloco{}
+	varredor := bufio.NewScanner(arquivo)
+	for número := 1; varredor.Scan(); número++ {
+		linha := varredor.Text()
+		if i := strings.IndexByte(linha, '#'); i >= 0 {
+			linha = linha[:i]
+		}
+		if strings.TrimSpace(linha) == "" {
+			continue
+		}
+		partes := strings.SplitN(linha, ";", 2)
+		faixa := strings.SplitN(strings.TrimSpace(partes[0]), "..", 2)
+		if len(partes) != 2 || len(faixa) != 2 {
+			return nil, fmt.Errorf("Blocks.txt:%d: esperado \"INÍCIO..FIM; Nome\"", número)
+		}
+		início, err1 := strconv.ParseInt(faixa[0], 16, 32)
+		fim, err2 := strconv.ParseInt(faixa[1], 16, 32)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("Blocks.txt:%d: faixa inválida: %q", número, partes[0])
+		}
+		blocos = append(blocos, bloco{Início: rune(início), Fim: rune(fim), Nome: strings.TrimSpace(partes[1])})
+	}
+	return blocos, varredor.Err()
+}
+
+// blocoDe devolve o nome do bloco da runa; os blocos estão em ordem
+func blocoDe(blocos []bloco, runa rune) string {
+	i := sort.Search(len(blocos), func(i int) bool { return blocos[i].Fim >= runa })
+	if i < len(blocos) && blocos[i].Início <= runa {
+		return blocos[i].Nome
+	}
+	return ""
+}
+
+// abrirBlocos carrega o Blocks.txt ao lado do arquivo em UCD_PATH, ou do
+// diretório de dados, baixando se preciso
+func abrirBlocos(cfg *configuração) ([]bloco, error) {
+	var arquivo io.ReadCloser
+	var err error
+	caminho := cfg.caminhoExplícito()
+	switch {
+	case strings.HasSuffix(caminho, ".zip"):
+		arquivo, err = abrirDoZip(caminho, "Blocks.txt")
+	case caminho != "":
+		arquivo, err = os.Open(filepath.Join(filepath.Dir(caminho), "Blocks.txt"))
+	default:
+		arquivo, err = cfg.repositório().abrir("Blocks.txt")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer arquivo.Close()
+	return carregarBlocos(arquivo)
+}
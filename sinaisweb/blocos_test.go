@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const blocosDeTeste = `# Blocks-15.1.0.txt
+# Date: 2023-07-28
+
+0000..007F; Basic Latin
+0080..00FF; Latin-1 Supplement # comentário
+2600..26FF; Miscellaneous Symbols
+`
+
+func TestCarregarBlocos(t *testing.T) {
+	blocos, err := carregarBlocos(strings.NewReader(blocosDeTeste))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocos) != 3 || blocos[1] != (bloco{0x80, 0xFF, "Latin-1 Supplement"}) {
+		t.Errorf("carregarBlocos: %v", blocos)
+	}
+	if _, err := carregarBlocos(strings.NewReader("0000-007F; Basic Latin\n")); err == nil {
+		t.Errorf("faixa sem '..' deveria dar erro")
+	}
+}
+
+func TestBlocoDe(t *testing.T) {
+	blocos, _ := carregarBlocos(strings.NewReader(blocosDeTeste))
+	casos := []struct {
+		runa     rune
+		esperado string
+	}{
+		{'A', "Basic Latin"},
+		{0x7F, "Basic Latin"},
+		{'é', "Latin-1 Supplement"},
+		{'☃', "Miscellaneous Symbols"},
+		{0x0100, ""},
+		{0x1F600, ""},
+	}
+	for _, caso := range casos {
+		if obtido := blocoDe(blocos, caso.runa); obtido != caso.esperado {
+			t.Errorf("blocoDe(U+%04X)\nesperado: %q; recebido: %q", caso.runa, caso.esperado, obtido)
+		}
+	}
+}
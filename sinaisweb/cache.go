@@ -0,0 +1,197 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	cacheResultadosPadrão = 200000 // resultados guardados no total, 4 bytes cada
+	controleCache         = "public, max-age=300"
+)
+
+// errConsultaVazia recusa consultas sem palavras, que encontrariam todos
+// os caracteres
+var errConsultaVazia = errors.New("a consulta não tem palavras")
+
+// cacheConsultas guarda os índices dos resultados das consultas mais
+// recentes, descartando as menos usadas quando o total de resultados
+// guardados passa da capacidade
+type cacheConsultas struct {
+	mu         sync.Mutex
+	capacidade int // total de resultados
+	total      int
+	ordem      *list.List // da mais à menos recente; valores *entradaCache
+	entradas   map[string]*list.Element
+}
+
+type entradaCache struct {
+	chave   string
+	índices []int32
+}
+
+// novoCacheConsultas devolve nil, que não guarda nada, se a capacidade é 0
+func novoCacheConsultas(capacidade int) *cacheConsultas {
+	if capacidade <= 0 {
+		return nil
+	}
+	return &cacheConsultas{capacidade: capacidade, ordem: list.New(), entradas: map[string]*list.Element{}}
+}
+
+// chaveConsulta normaliza a consulta: a ordem e a repetição das palavras
+// não mudam os resultados
+func chaveConsulta(consulta string) string {
+	termos := separar(consulta)
+	sort.Strings(termos)
+	únicos := termos[:0]
+	for i, termo := range termos {
+		if i == 0 || termo != termos[i-1] {
+			únicos = append(únicos, termo)
+		}
+	}
+	return strings.Join(únicos, " ")
+}
+
+// obter devolve os resultados guardados da chave ou os calcula e guarda.
+// O cálculo é feito fora da trava; consultas iguais simultâneas podem ser
+// calculadas mais de uma vez. Erros e resultados maiores que a capacidade
+// não são guardados.
+func (c *cacheConsultas) obter(chave string, calcular func() ([]int32, error)) ([]int32, error) {
+	if c == nil {
+		return calcular()
+	}
+	c.mu.Lock()
+	if elemento, ok := c.entradas[chave]; ok {
+		c.ordem.MoveToFront(elemento)
+		c.mu.Unlock()
+		return elemento.Value.(*entradaCache).índices, nil
+	}
+	c.mu.Unlock()
+
+	índices, err := calcular()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entradas[chave]; ok || len(índices) > c.capacidade {
+		return índices, nil
+	}
+	for c.total+len(índices) > c.capacidade {
+		antiga := c.ordem.Remove(c.ordem.Back()).(*entradaCache)
+		delete(c.entradas, antiga.chave)
+		c.total -= len(antiga.índices)
+	}
+	c.entradas[chave] = c.ordem.PushFront(&entradaCache{chave, índices})
+	c.total += len(índices)
+	return índices, nil
+}
+
+// tamanho devolve o número de consultas e o total de resultados guardados
+func (c *cacheConsultas) tamanho() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ordem.Len(), c.total
+}
+
+// filtrar é o Filtrar das páginas e da API, passando pelo cache e
+// respeitando o prazo da requisição; devolve as posições dos resultados
+// em d.linhas
+func (d *dadosWeb) filtrar(ctx context.Context, consulta string) ([]int32, error) {
+	chave := chaveConsulta(consulta)
+	if chave == "" {
+		return nil, errConsultaVazia
+	}
+	return d.consultas.obter(chave, func() ([]int32, error) { return filtrarÍndices(ctx, d.linhas, chave) })
+}
+
+// identificarDados resume a versão e tudo o que muda as respostas de uma
+// mesma requisição: o conteúdo carregado, os blocos, os sinônimos e o
+// limite de página
+func identificarDados(versão string, d *dadosWeb) string {
+	soma := sha256.New()
+	for _, linha := range d.linhas {
+		fmt.Fprintf(soma, "%s\n", linha)
+	}
+	for _, b := range d.blocos {
+		fmt.Fprintf(soma, "bloco %X %X %s\n", b.Início, b.Fim, b.Nome)
+	}
+	chaves := make([]string, 0, len(d.sinônimos))
+	for chave := range d.sinônimos {
+		chaves = append(chaves, chave)
+	}
+	sort.Strings(chaves)
+	for _, chave := range chaves {
+		fmt.Fprintf(soma, "sinônimo %q %q\n", chave, d.sinônimos[chave])
+	}
+	fmt.Fprintf(soma, "página %d %d\n", limitePadrão, d.páginaMáxima)
+	return fmt.Sprintf("%s-%s", versão, hex.EncodeToString(soma.Sum(nil))[:16])
+}
+
+// etagDe deriva a ETag da identidade dos dados e da requisição, com os
+// parâmetros em ordem canônica. É fraca porque a mesma resposta pode ser
+// enviada com ou sem compressão.
+func etagDe(identidade string, r *http.Request) string {
+	soma := sha256.Sum256([]byte(identidade + "\x00" + r.URL.Path + "?" + r.URL.Query().Encode()))
+	return `W/"` + hex.EncodeToString(soma[:12]) + `"`
+}
+
+// correspondeEtag interpreta o If-None-Match, que pode listar várias ETags
+func correspondeEtag(cabeçalho, etag string) bool {
+	for _, candidata := range strings.Split(cabeçalho, ",") {
+		candidata = strings.TrimSpace(candidata)
+		if candidata == "*" || strings.TrimPrefix(candidata, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// gravadorCacheável acrescenta ETag e Cache-Control só às respostas 200,
+// para que erros não fiquem guardados nos clientes
+type gravadorCacheável struct {
+	http.ResponseWriter
+	etag     string
+	escreveu bool
+}
+
+func (g *gravadorCacheável) WriteHeader(status int) {
+	if !g.escreveu && status == http.StatusOK {
+		g.Header().Set("ETag", g.etag)
+		g.Header().Set("Cache-Control", controleCache)
+	}
+	g.escreveu = true
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gravadorCacheável) Write(p []byte) (int, error) {
+	if !g.escreveu {
+		g.WriteHeader(http.StatusOK)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+func (g *gravadorCacheável) Unwrap() http.ResponseWriter { return g.ResponseWriter }
+
+// cacheável responde 304 quando o cliente já tem a versão atual da
+// resposta e marca as respostas novas com ETag e Cache-Control
+func (d *dadosWeb) cacheável(tratador http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := etagDe(d.identidade, r)
+		if correspondeEtag(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", controleCache)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		tratador(&gravadorCacheável{ResponseWriter: w, etag: etag}, r)
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCacheConsultas(t *testing.T) {
+	cálculos := []string{}
+	cache := novoCacheConsultas(5)
+	obter := func(chave string, n int) {
+		cache.obter(chave, func() ([]int32, error) {
+			cálculos = append(cálculos, chave)
+			return make([]int32, n), nil
+		})
+	}
+	obter("A", 2)
+	obter("B", 2)
+	obter("A", 2)
+	obter("C", 3) // expulsa B, a menos recente, e cabe com A
+	obter("B", 2) // expulsa A
+	obter("D", 6) // maior que a capacidade: não é guardada
+	obter("D", 6)
+	esperado := []string{"A", "B", "C", "B", "D", "D"}
+	if !reflect.DeepEqual(cálculos, esperado) {
+		t.Errorf("consultas calculadas\nesperado: %q; recebido: %q", esperado, cálculos)
+	}
+	if consultas, resultados := cache.tamanho(); consultas != 2 || resultados != 5 {
+		t.Errorf("tamanho do cache\nesperado: 2 consultas, 5 resultados; recebido: %d, %d", consultas, resultados)
+	}
+}
+
+func TestCacheConsultas_desativado(t *testing.T) {
+	cache := novoCacheConsultas(0)
+	cálculos := 0
+	for i := 0; i < 2; i++ {
+		cache.obter("A", func() ([]int32, error) { cálculos++; return nil, nil })
+	}
+	if cache != nil || cálculos != 2 {
+		t.Errorf("cache de capacidade 0 deveria calcular sempre: %d cálculos", cálculos)
+	}
+}
+
+func TestChaveConsulta(t *testing.T) {
+	casos := []struct {
+		consulta string
+		chave    string
+	}{
+		{"LETTER", "LETTER"},
+		{"LETTER LETTER LETTER", "LETTER"},
+		{"SMALL LATIN-LETTER  A", "A LATIN LETTER SMALL"},
+		{"LATIN SMALL A LETTER", "A LATIN LETTER SMALL"},
+		{"- -", ""},
+	}
+	for _, caso := range casos {
+		if obtido := chaveConsulta(caso.consulta); obtido != caso.chave {
+			t.Errorf("chaveConsulta(%q)\nesperado: %q; recebido: %q", caso.consulta, caso.chave, obtido)
+		}
+	}
+}
+
+func TestDadosWebFiltrar(t *testing.T) {
+	dados := novosDadosDeTeste()
+	dados.consultas = novoCacheConsultas(100)
+	índices, err := dados.filtrar(context.Background(), "SIGN")
+	if err != nil || len(índices) != 2 {
+		t.Errorf("filtrar(SIGN): %v, %v", índices, err)
+	}
+	dados.filtrar(context.Background(), "SIGN SIGN")
+	if consultas, _ := dados.consultas.tamanho(); consultas != 1 {
+		t.Errorf("consultas equivalentes deveriam ocupar uma só entrada: %d", consultas)
+	}
+	if _, err := dados.filtrar(context.Background(), "-"); err != errConsultaVazia {
+		t.Errorf("filtrar(-)\nesperado: %v; recebido: %v", errConsultaVazia, err)
+	}
+}
+
+func TestIdentificarDados(t *testing.T) {
+	linhas := carregar(strings.NewReader(linhas3Da43))
+	dados := func(mudar func(*dadosWeb)) *dadosWeb {
+		d := &dadosWeb{linhas: linhas, sinônimos: map[string]string{"A": "LETTER"}, páginaMáxima: 200}
+		if mudar != nil {
+			mudar(d)
+		}
+		return d
+	}
+	base := identificarDados("15.1.0", dados(nil))
+	if base != identificarDados("15.1.0", dados(nil)) {
+		t.Errorf("identidade deveria ser estável")
+	}
+	if base == identificarDados("15.0.0", dados(nil)) {
+		t.Errorf("identidade deveria mudar com a versão")
+	}
+	casos := map[string]func(*dadosWeb){
+		"conteúdo":  func(d *dadosWeb) { d.linhas = linhas[1:] },
+		"sinônimos": func(d *dadosWeb) { d.sinônimos = map[string]string{"A": "CAPITAL"} },
+		"blocos":    func(d *dadosWeb) { d.blocos = []bloco{{0, 0x7F, "Basic Latin"}} },
+		"página":    func(d *dadosWeb) { d.páginaMáxima = 10 },
+	}
+	for mudança, mudar := range casos {
+		if identificarDados("15.1.0", dados(mudar)) == base {
+			t.Errorf("identidade deveria mudar com: %s", mudança)
+		}
+	}
+}
+
+func TestCacheável_sinônimosMudamEtag(t *testing.T) {
+	etag := func(sinônimos map[string]string) string {
+		s := novoServiço(opçõesServidor{})
+		dados := novosDadosDeTeste()
+		dados.sinônimos = sinônimos
+		s.carregar(dados, "15.1.0")
+		gravador := httptest.NewRecorder()
+		s.ServeHTTP(gravador, httptest.NewRequest("GET", "/api/v1/busca?q=letra", nil))
+		return gravador.Header().Get("ETag")
+	}
+	antes, depois := etag(map[string]string{"LETRA": "LETTER"}), etag(map[string]string{"LETRA": "SIGN"})
+	if antes == "" || antes == depois {
+		t.Errorf("ETag deveria mudar com os sinônimos\nantes: %q; depois: %q", antes, depois)
+	}
+}
+
+func TestCorrespondeEtag(t *testing.T) {
+	casos := []struct {
+		cabeçalho string
+		esperado  bool
+	}{
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`"x", W/"abc"`, true},
+		{`*`, true},
+		{`W/"abd"`, false},
+		{``, false},
+	}
+	for _, caso := range casos {
+		if obtido := correspondeEtag(caso.cabeçalho, `W/"abc"`); obtido != caso.esperado {
+			t.Errorf("correspondeEtag(%q)\nesperado: %v; recebido: %v", caso.cabeçalho, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestCacheável(t *testing.T) {
+	a := novaAPIDeTeste(t)
+	a.identidade = "15.1.0-teste"
+	gravador := httptest.NewRecorder()
+	a.ServeHTTP(gravador, httptest.NewRequest("GET", "/api/v1/busca?q=sign&limite=5", nil))
+	etag := gravador.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) || gravador.Header().Get("Cache-Control") != controleCache {
+		t.Fatalf("resposta sem ETag ou Cache-Control: %v", gravador.Header())
+	}
+
+	// a mesma consulta com os parâmetros em outra ordem tem a mesma ETag
+	requisição := httptest.NewRequest("GET", "/api/v1/busca?limite=5&q=sign", nil)
+	requisição.Header.Set("If-None-Match", etag)
+	gravador = httptest.NewRecorder()
+	a.ServeHTTP(gravador, requisição)
+	if gravador.Code != http.StatusNotModified || gravador.Body.Len() != 0 {
+		t.Errorf("If-None-Match com a ETag atual\nesperado: 304 sem corpo; recebido: %d %q", gravador.Code, gravador.Body.String())
+	}
+
+	a.identidade = "15.2.0-teste"
+	gravador = httptest.NewRecorder()
+	a.ServeHTTP(gravador, requisição)
+	if gravador.Code != http.StatusOK {
+		t.Errorf("If-None-Match depois de mudar os dados\nesperado: 200; recebido: %d", gravador.Code)
+	}
+
+	gravador = httptest.NewRecorder()
+	a.ServeHTTP(gravador, httptest.NewRequest("GET", "/api/v1/busca", nil))
+	if gravador.Header().Get("ETag") != "" || gravador.Header().Get("Cache-Control") != "" {
+		t.Errorf("respostas de erro não deveriam ser cacheáveis: %v", gravador.Header())
+	}
+}
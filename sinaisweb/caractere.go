@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Caractere reúne os campos de uma linha do UnicodeData.txt
+type Caractere struct {
+	Runa          rune
+	Nome          string
+	Categoria     string
+	Combinação    string
+	Bidi          string
+	Decomposição  string
+	ValorDecimal  string
+	ValorDígito   string
+	ValorNumérico string
+	Espelhado     bool
+	NomeAntigo    string
+	Maiúscula     rune
+	Minúscula     rune
+	Título        rune
+}
+
+// AnalisarCaractere devolve todos os campos de uma linha do UnicodeData.txt
+func AnalisarCaractere(linha string) Caractere {
+	campos := strings.Split(linha, ";")
+	for len(campos) < 15 {
+		campos = append(campos, "")
+	}
+	código, _ := strconv.ParseInt(campos[0], 16, 32)
+	return Caractere{
+		Runa:          rune(código),
+		Nome:          campos[1],
+		Categoria:     campos[2],
+		Combinação:    campos[3],
+		Bidi:          campos[4],
+		Decomposição:  campos[5],
+		ValorDecimal:  campos[6],
+		ValorDígito:   campos[7],
+		ValorNumérico: campos[8],
+		Espelhado:     campos[9] == "Y",
+		NomeAntigo:    campos[10],
+		Maiúscula:     runaOpcional(campos[12]),
+		Minúscula:     runaOpcional(campos[13]),
+		Título:        runaOpcional(campos[14]),
+	}
+}
+
+func runaOpcional(campo string) rune {
+	código, err := strconv.ParseInt(campo, 16, 32)
+	if err != nil {
+		return 0
+	}
+	return rune(código)
+}
+
+// analisarCódigo aceita "U+2603", "0x2603", "2603" ou o próprio caractere
+// "☃". Um texto de um só caractere é sempre o próprio caractere: "5" e
+// "A" são U+0035 e U+0041; para U+0005 e U+000A use "U+5" ou "0A".
+func analisarCódigo(s string) (rune, error) {
+	if utf8.RuneCountInString(s) == 1 {
+		runa, _ := utf8.DecodeRuneInString(s)
+		return runa, nil
+	}
+	hexa := strings.ToUpper(s)
+	if strings.HasPrefix(hexa, "U+") || strings.HasPrefix(hexa, "0X") {
+		hexa = hexa[2:]
+	}
+	código, err := strconv.ParseInt(hexa, 16, 32)
+	if err != nil || código < 0 || código > utf8.MaxRune {
+		return 0, fmt.Errorf("código inválido: %q", s)
+	}
+	return rune(código), nil
+}
+
+// buscarCaractere localiza a linha do UnicodeData.txt que descreve a runa.
+// Runas dentro de uma faixa <…, First>/<…, Last> recebem os campos da
+// linha First e o nome derivado da faixa.
+func buscarCaractere(linhas []string, runa rune) (Caractere, bool) {
+	prefixo := fmt.Sprintf("%04X;", runa)
+	for i, linha := range linhas {
+		if início, fim, ok := faixaEm(linhas, i); ok {
+			if início.Runa <= runa && runa <= fim {
+				return caractereNaFaixa(início, runa), true
+			}
+			continue
+		}
+		if strings.HasPrefix(linha, prefixo) {
+			return AnalisarCaractere(linha), true
+		}
+	}
+	return Caractere{}, false
+}
+
+// faixaEm reconhece a linha First de uma faixa seguida da sua linha Last
+func faixaEm(linhas []string, i int) (Caractere, rune, bool) {
+	if !strings.Contains(linhas[i], ", First>;") || i+1 >= len(linhas) || !strings.Contains(linhas[i+1], ", Last>;") {
+		return Caractere{}, 0, false
+	}
+	return AnalisarCaractere(linhas[i]), AnalisarCaractere(linhas[i+1]).Runa, true
+}
+
+// caractereNaFaixa copia os campos da linha First para a runa
+func caractereNaFaixa(início Caractere, runa rune) Caractere {
+	início.Runa = runa
+	início.Nome = nomeNaFaixa(início.Nome, runa)
+	return início
+}
+
+// nomeNaFaixa deriva o nome de uma runa de faixa como descreve a seção
+// 4.8 do UAX #44; faixas sem nome recebem o rótulo do código, como
+// <private-use-E000>
+func nomeNaFaixa(rótulo string, runa rune) string {
+	faixa := strings.TrimSuffix(strings.TrimPrefix(rótulo, "<"), ", First>")
+	switch {
+	case strings.HasPrefix(faixa, "CJK Ideograph"):
+		return fmt.Sprintf("CJK UNIFIED IDEOGRAPH-%04X", runa)
+	case strings.HasPrefix(faixa, "Tangut Ideograph"):
+		return fmt.Sprintf("TANGUT IDEOGRAPH-%04X", runa)
+	case faixa == "Hangul Syllable":
+		return nomeHangul(runa)
+	case strings.Contains(faixa, "Surrogate"):
+		return fmt.Sprintf("<surrogate-%04X>", runa)
+	case strings.Contains(faixa, "Private Use"):
+		return fmt.Sprintf("<private-use-%04X>", runa)
+	}
+	return fmt.Sprintf("<%s-%04X>", strings.ToLower(strings.ReplaceAll(faixa, " ", "-")), runa)
+}
+
+var (
+	jamosIniciais = []string{"G", "GG", "N", "D", "DD", "R", "M", "B", "BB", "S", "SS", "", "J", "JJ", "C", "K", "T", "P", "H"}
+	jamosMediais  = []string{"A", "AE", "YA", "YAE", "EO", "E", "YEO", "YE", "O", "WA", "WAE", "OE", "YO", "U", "WEO", "WE", "WI", "YU", "EU", "YI", "I"}
+	jamosFinais   = []string{"", "G", "GG", "GS", "N", "NJ", "NH", "D", "L", "LG", "LM", "LB", "LS", "LT", "LP", "LH", "M", "B", "BS", "S", "SS", "NG", "J", "C", "K", "T", "P", "H"}
+)
+
+// nomeHangul compõe o nome de uma sílaba Hangul a partir dos seus jamos
+func nomeHangul(runa rune) string {
+	const base, porInicial, porMedial = 0xAC00, 21 * 28, 28
+	s := int(runa - base)
+	return "HANGUL SYLLABLE " + jamosIniciais[s/porInicial] + jamosMediais[s%porInicial/porMedial] + jamosFinais[s%porMedial]
+}
+
+// propriedade é um par rótulo e valor exibido no detalhe de um caractere
+type propriedade struct {
+	Rótulo string
+	Valor  string
+}
+
+// propriedades lista as propriedades preenchidas do caractere, na ordem
+// em que são exibidas
+func propriedades(c Caractere) []propriedade {
+	lista := []propriedade{}
+	adicionar := func(rótulo, valor string) {
+		if valor != "" {
+			lista = append(lista, propriedade{rótulo, valor})
+		}
+	}
+	adicionar("categoria", c.Categoria)
+	adicionar("combinação", c.Combinação)
+	adicionar("bidi", c.Bidi)
+	adicionar("decomposição", c.Decomposição)
+	adicionar("valor", c.ValorNumérico)
+	if c.Espelhado {
+		adicionar("espelhado", "sim")
+	}
+	adicionar("nome antigo", c.NomeAntigo)
+	adicionar("maiúscula", formatarRuna(c.Maiúscula))
+	adicionar("minúscula", formatarRuna(c.Minúscula))
+	adicionar("título", formatarRuna(c.Título))
+	adicionar("UTF-8", formatarBytes([]byte(string(c.Runa))))
+	return lista
+}
+
+// Detalhar produz texto com todas as propriedades do caractere
+func Detalhar(c Caractere) string {
+	var texto strings.Builder
+	fmt.Fprintf(&texto, "U+%04X\t%[1]c\t%s\n", c.Runa, c.Nome)
+	for _, p := range propriedades(c) {
+		fmt.Fprintf(&texto, "  %-14s %s\n", p.Rótulo+":", p.Valor)
+	}
+	return texto.String()
+}
+
+func formatarRuna(r rune) string {
+	if r == 0 {
+		return ""
+	}
+	return fmt.Sprintf("U+%04X %c", r, r)
+}
+
+func formatarBytes(bytes []byte) string {
+	partes := make([]string, len(bytes))
+	for i, b := range bytes {
+		partes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(partes, " ")
+}
+
+// Descrever produz texto com código, runa e nome de cada caractere do texto
+func Descrever(linhas []string, texto string) string {
+	var saída strings.Builder
+	for _, runa := range texto {
+		nome := "(sem nome)"
+		if caractere, ok := buscarCaractere(linhas, runa); ok {
+			nome = caractere.Nome
+			if caractere.NomeAntigo != "" {
+				nome += fmt.Sprintf(" (%s)", caractere.NomeAntigo)
+			}
+		}
+		fmt.Fprintf(&saída, "U+%04X\t%[1]c\t%s\n", runa, nome)
+	}
+	return saída.String()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAnalisarCaractere(t *testing.T) {
+	c := AnalisarCaractere("00E9;LATIN SMALL LETTER E WITH ACUTE;Ll;0;L;0065 0301;;;;N;LATIN SMALL LETTER E ACUTE;;00C9;;00C9")
+	if c.Runa != 'é' || c.Categoria != "Ll" || c.Decomposição != "0065 0301" {
+		t.Errorf("AnalisarCaractere -> %#v", c)
+	}
+	if c.NomeAntigo != "LATIN SMALL LETTER E ACUTE" || c.Maiúscula != 'É' || c.Minúscula != 0 {
+		t.Errorf("AnalisarCaractere -> %#v", c)
+	}
+}
+
+func TestAnalisarCódigo(t *testing.T) {
+	casos := []struct {
+		texto    string
+		esperado rune
+		erro     bool
+	}{
+		{"U+2603", '☃', false},
+		{"u+0041", 'A', false},
+		{"1F638", '😸', false},
+		{"☃", '☃', false},
+		{"7", '7', false},
+		{"A", 'A', false},
+		{"U+5", 0x05, false},
+		{"0x0A", 0x0A, false},
+		{"0A", 0x0A, false},
+		{"U+", 0, true},
+		{"U+XYZ", 0, true},
+		{"110000", 0, true},
+	}
+	for _, caso := range casos {
+		obtido, err := analisarCódigo(caso.texto)
+		if obtido != caso.esperado || (err != nil) != caso.erro {
+			t.Errorf("analisarCódigo(%q)\nesperado: %q, erro %v; recebido: %q, %v",
+				caso.texto, caso.esperado, caso.erro, obtido, err)
+		}
+	}
+}
+
+func TestBuscarCaractere(t *testing.T) {
+	linhas := carregar(strings.NewReader(linhas3Da43))
+	if c, ok := buscarCaractere(linhas, '@'); !ok || c.Nome != "COMMERCIAL AT" {
+		t.Errorf("buscarCaractere('@') -> %#v, %v", c, ok)
+	}
+	if _, ok := buscarCaractere(linhas, 'Z'); ok {
+		t.Errorf("buscarCaractere('Z') não deveria encontrar")
+	}
+}
+
+const linhasFaixas = `4E00;<CJK Ideograph, First>;Lo;0;L;;;;;N;;;;;
+9FD5;<CJK Ideograph, Last>;Lo;0;L;;;;;N;;;;;
+AC00;<Hangul Syllable, First>;Lo;0;L;;;;;N;;;;;
+D7A3;<Hangul Syllable, Last>;Lo;0;L;;;;;N;;;;;
+E000;<Private Use, First>;Co;0;L;;;;;N;;;;;
+F8FF;<Private Use, Last>;Co;0;L;;;;;N;;;;;
+17000;<Tangut Ideograph, First>;Lo;0;L;;;;;N;;;;;
+187EC;<Tangut Ideograph, Last>;Lo;0;L;;;;;N;;;;;
+`
+
+func TestBuscarCaractere_faixas(t *testing.T) {
+	linhas := carregar(strings.NewReader(linhasFaixas))
+	casos := []struct {
+		runa rune
+		nome string
+	}{
+		{0x4E00, "CJK UNIFIED IDEOGRAPH-4E00"},
+		{'丁', "CJK UNIFIED IDEOGRAPH-4E01"},
+		{0x9FD5, "CJK UNIFIED IDEOGRAPH-9FD5"},
+		{'가', "HANGUL SYLLABLE GA"},
+		{'한', "HANGUL SYLLABLE HAN"},
+		{'힣', "HANGUL SYLLABLE HIH"},
+		{0xE123, "<private-use-E123>"},
+		{0x17001, "TANGUT IDEOGRAPH-17001"},
+	}
+	for _, caso := range casos {
+		c, ok := buscarCaractere(linhas, caso.runa)
+		if !ok || c.Nome != caso.nome || c.Runa != caso.runa {
+			t.Errorf("buscarCaractere(U+%04X)\nesperado: %q; recebido: %#v, %v", caso.runa, caso.nome, c, ok)
+		}
+	}
+	if c, _ := buscarCaractere(linhas, '丁'); c.Categoria != "Lo" {
+		t.Errorf("a runa deveria herdar os campos da linha First: %#v", c)
+	}
+	if _, ok := buscarCaractere(linhas, 0x9FD6); ok {
+		t.Errorf("U+9FD6 está fora da faixa e não deveria ser encontrado")
+	}
+}
+
+func ExampleDetalhar() {
+	fmt.Print(Detalhar(AnalisarCaractere("0041;LATIN CAPITAL LETTER A;Lu;0;L;;;;;N;;;;0061;")))
+	// Output:
+	// U+0041	A	LATIN CAPITAL LETTER A
+	//   categoria:     Lu
+	//   combinação:    0
+	//   bidi:          L
+	//   minúscula:     U+0061 a
+	//   UTF-8:         41
+}
+
+func ExampleDescrever() {
+	linhas := carregar(strings.NewReader(linhas3Da43))
+	fmt.Print(Descrever(linhas, "A=Z"))
+	// Output:
+	// U+0041	A	LATIN CAPITAL LETTER A
+	// U+003D	=	EQUALS SIGN
+	// U+005A	Z	(sem nome)
+}
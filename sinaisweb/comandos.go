@@ -0,0 +1,556 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// comando é um subcomando do sinais, com suas próprias opções e ajuda
+type comando struct {
+	nome     string
+	uso      string
+	resumo   string
+	oculto   bool
+	executar func(opções *flag.FlagSet, args []string) error
+}
+
+var comandos []*comando
+
+func init() {
+	comandos = []*comando{
+		{nome: "buscar", uso: "PALAVRA...",
+			resumo:   "lista caracteres cujo nome contém todas as palavras",
+			executar: executarBuscar},
+		{nome: "info", uso: "CÓDIGO...",
+			resumo:   "exibe as propriedades de caracteres (U+2603, 2603 ou ☃; um só dígito é o próprio caractere)",
+			executar: executarInfo},
+		{nome: "descrever", uso: "TEXTO...",
+			resumo:   "exibe o código e o nome de cada caractere do texto",
+			executar: executarDescrever},
+		{nome: "interativo", uso: "",
+			resumo:   "abre um prompt que mantém os dados carregados (o mesmo que: sinais -i)",
+			executar: executarInterativo},
+		{nome: "escolher", uso: "[PALAVRA...]",
+			resumo:   "abre uma tela que filtra enquanto você digita e exibe os caracteres escolhidos",
+			executar: executarEscolher},
+		{nome: "servir", uso: "",
+			resumo:   "sobe um servidor HTTP para receber consultas",
+			executar: executarServir},
+		{nome: "dados", uso: "",
+			resumo:   "lista os arquivos de dados Unicode, suas versões e somas SHA-256",
+			executar: executarDados},
+		{nome: "atualizar", uso: "",
+			resumo:   "instala uma versão da UCD, lista as versões instaladas ou escolhe a ativa",
+			executar: executarAtualizar},
+		{nome: "diff", uso: "VERSÃO VERSÃO",
+			resumo:   "lista o que mudou entre duas versões instaladas da UCD",
+			executar: executarDiff},
+		{nome: "config", uso: "",
+			resumo:   "exibe a configuração efetiva e a origem de cada valor",
+			executar: executarConfig},
+		{nome: "completion", uso: "bash|fish|zsh",
+			resumo:   "gera o script de completamento para o shell",
+			executar: executarCompletion},
+		{nome: "__completar", uso: "PALAVRA...", oculto: true,
+			resumo:   "lista candidatos para os scripts de completamento",
+			executar: executarCompletar},
+		{nome: "ajuda", uso: "[COMANDO]",
+			resumo:   "exibe a ajuda geral ou de um comando",
+			executar: executarAjuda},
+	}
+}
+
+func acharComando(nome string) *comando {
+	for _, cmd := range comandos {
+		if cmd.nome == nome {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// opções cria o conjunto de opções do comando, com mensagem de uso própria
+func (cmd *comando) opções(saída io.Writer) *flag.FlagSet {
+	opções := flag.NewFlagSet(cmd.nome, flag.ContinueOnError)
+	opções.SetOutput(saída)
+	opções.Usage = func() {
+		fmt.Fprintf(saída, "uso: sinais %s [opções] %s\n\n%s\n", cmd.nome, cmd.uso, cmd.resumo)
+		temOpções := false
+		opções.VisitAll(func(*flag.Flag) { temOpções = true })
+		if temOpções {
+			fmt.Fprintln(saída, "\nopções:")
+			opções.PrintDefaults()
+		}
+	}
+	return opções
+}
+
+// rodar executa o comando com os argumentos, tratando -h como sucesso
+func (cmd *comando) rodar(args []string) error {
+	opções := cmd.opções(os.Stderr)
+	err := cmd.executar(opções, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// Executar interpreta a linha de comando. Se o primeiro argumento não é um
+// subcomando, as palavras são tratadas como uma busca implícita, e as
+// opções vão para o comando buscar: ao contrário de antes dos subcomandos,
+// uma opção desconhecida, como em "sinais -x cat", é um erro, não ignorada.
+func Executar(args []string) error {
+	if len(args) == 0 {
+		return acharComando("ajuda").rodar(nil)
+	}
+	if args[0] == "-h" || args[0] == "--help" || args[0] == "-help" {
+		return acharComando("ajuda").rodar(args[1:])
+	}
+	if args[0] == "-i" {
+		return acharComando("interativo").rodar(args[1:])
+	}
+	if cmd := acharComando(args[0]); cmd != nil {
+		return cmd.rodar(args[1:])
+	}
+	opções, palavras := extrairOpções(args)
+	if len(opções) == 0 && len(palavras) == 0 { // só argumentos vazios
+		return acharComando("ajuda").rodar(nil)
+	}
+	if contém(opções, "-w") { // compatibilidade com a opção antiga
+		return acharComando("servir").rodar(palavras)
+	}
+	return acharComando("buscar").rodar(args)
+}
+
+func opçãoFormato(opções *flag.FlagSet, cfg *configuração) {
+	opções.String("formato", cfg.Formato, "formato de saída: "+nomesFormatos())
+}
+
+// opçõesDeDownload define as opções dos comandos que baixam dados
+func opçõesDeDownload(opções *flag.FlagSet, cfg *configuração) {
+	opções.Int("tentativas", cfg.Tentativas, "número máximo de tentativas de cada download")
+	opções.Duration("tempo-limite", cfg.TempoLimite, "tempo máximo para conectar ou ficar sem receber dados")
+	opções.String("progresso", cfg.Progresso, "exibição do progresso: "+strings.Join(modosProgresso, ", "))
+	opções.String("espelhos", strings.Join(cfg.Espelhos, ","),
+		"servidores, URLs file:// ou diretórios de onde baixar os dados, separados por vírgulas e tentados em ordem")
+	opções.String("proxy", cfg.Proxy, "URL do proxy HTTP (padrão: HTTP_PROXY e HTTPS_PROXY)")
+	opções.String("ca", cfg.CA, "arquivo PEM com certificados de autoridades aceitos além dos do sistema")
+	opções.Bool("inseguro", cfg.Inseguro, "não verifica certificados TLS; use só para diagnóstico")
+}
+
+func opçãoVersão(opções *flag.FlagSet, cfg *configuração) {
+	opções.String("versao", cfg.Versão, "versão da UCD consultada, como 15.1.0 (padrão: a versão ativa)")
+}
+
+// analisarOpções interpreta os argumentos e registra na configuração as
+// opções informadas, que têm precedência sobre os demais valores
+func analisarOpções(opções *flag.FlagSet, cfg *configuração, args []string) error {
+	if err := opções.Parse(args); err != nil {
+		return err
+	}
+	return cfg.aplicarOpções(opções)
+}
+
+func executarBuscar(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoFormato(opções, cfg)
+	opçãoVersão(opções, cfg)
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		return err
+	}
+	consulta := strings.ToUpper(strings.Join(opções.Args(), " "))
+	consulta = ExpandirSinônimos(consulta, cfg.sinônimosAtivos())
+	return Formatar(os.Stdout, Filtrar(linhas, consulta), cfg.Formato)
+}
+
+// exibirInfo escreve as propriedades dos caracteres indicados pelos códigos
+func exibirInfo(w io.Writer, linhas []string, códigos []string, formato string) error {
+	caracteres := []Caractere{}
+	for _, arg := range códigos {
+		runa, err := analisarCódigo(arg)
+		if err != nil {
+			return err
+		}
+		caractere, ok := buscarCaractere(linhas, runa)
+		if !ok {
+			return fmt.Errorf("U+%04X não encontrado", runa)
+		}
+		caracteres = append(caracteres, caractere)
+	}
+	if formato != "texto" {
+		return Formatar(w, caracteres, formato)
+	}
+	for _, caractere := range caracteres {
+		fmt.Fprint(w, Detalhar(caractere))
+	}
+	return nil
+}
+
+func executarInfo(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoFormato(opções, cfg)
+	opçãoVersão(opções, cfg)
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	if opções.NArg() == 0 {
+		opções.Usage()
+		return errors.New("informe ao menos um código")
+	}
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		return err
+	}
+	return exibirInfo(os.Stdout, linhas, opções.Args(), cfg.Formato)
+}
+
+func executarDescrever(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoVersão(opções, cfg)
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Print(Descrever(linhas, strings.Join(opções.Args(), " ")))
+	return nil
+}
+
+func executarInterativo(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoFormato(opções, cfg)
+	opçãoVersão(opções, cfg)
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		return err
+	}
+	s := &sessão{linhas: linhas, formato: cfg.Formato, sinônimos: cfg.sinônimosAtivos(), saída: os.Stdout}
+	return Interagir(s, os.Stdin)
+}
+
+func executarEscolher(opções *flag.FlagSet, args []string) error {
+	opções.Usage = func() {
+		cmd := acharComando("escolher")
+		fmt.Fprintf(opções.Output(), "uso: sinais escolher [opções] %s\n\n%s\n", cmd.uso, cmd.resumo)
+		fmt.Fprintln(opções.Output(), "\nteclas: ↑/↓ navegam, Tab marca, Enter escolhe, Esc cancela")
+		fmt.Fprintln(opções.Output(), "\nopções:")
+		opções.PrintDefaults()
+	}
+	formato := opções.String("formato", "caractere", "formato de saída: "+nomesFormatos())
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoVersão(opções, cfg)
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		return err
+	}
+	escolhidos, err := Escolher(linhas, strings.Join(opções.Args(), " "))
+	if err != nil {
+		return err
+	}
+	return Formatar(os.Stdout, escolhidos, *formato)
+}
+
+func executarServir(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoVersão(opções, cfg)
+	opções.String("endereco", cfg.Endereço, "endereço onde o servidor HTTP escuta")
+	opções.Int("pagina-maxima", cfg.PáginaMáxima, "maior número de resultados por página aceito dos clientes")
+	opções.Int("cache", cfg.Cache, "total de resultados de consultas recentes guardados em memória (0 desativa)")
+	opções.Float64("limite-taxa", cfg.Taxa, "requisições por segundo aceitas de cada cliente (0 desativa); "+
+		"atrás de um proxy ou balanceador, configure também -proxies-confiaveis")
+	opções.Int("limite-rajada", cfg.Rajada, "requisições seguidas aceitas de um cliente antes do limite de taxa")
+	opções.String("proxies-confiaveis", strings.Join(cfg.Confiáveis, ","), "IPs ou redes cujo X-Forwarded-For identifica o cliente")
+	opções.Int("consulta-maxima", cfg.ConsultaMáxima, "maior número de caracteres de cada parâmetro (0 desativa)")
+	opções.Duration("orcamento", cfg.Orçamento, "tempo máximo de cada consulta (0 desativa)")
+	opções.String("url-publica", cfg.URLPública, "endereço público do serviço usado no /opensearch.xml (padrão: o da requisição)")
+	opções.String("log-nivel", cfg.NívelLog, "nível mínimo das mensagens de log: "+nomesNíveisLog())
+	opções.String("log-formato", cfg.FormatoLog, "formato das mensagens de log: "+strings.Join(formatosLog, ", "))
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	usarRegistrador(cfg)
+	proteções, err := opçõesDeProteção(cfg)
+	if err != nil {
+		return err
+	}
+	return IniciarServidor(cfg.Endereço, proteções, func() (*dadosWeb, string, error) {
+		linhas, err := carregarUCD(cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		blocos, err := abrirBlocos(cfg)
+		if err != nil {
+			slog.Warn("/api/v1/blocos indisponível", "erro", err)
+		}
+		dados := &dadosWeb{linhas: linhas, blocos: blocos, sinônimos: cfg.sinônimosAtivos(), páginaMáxima: cfg.PáginaMáxima,
+			sugestões: novoSugestor(linhas), consultas: novoCacheConsultas(cfg.Cache)}
+		return dados, versãoCarregada(cfg), nil
+	})
+}
+
+// opçõesDeProteção monta as opções do servidor a partir da configuração e
+// avisa quando o limite de taxa não distingue os clientes atrás de um proxy
+func opçõesDeProteção(cfg *configuração) (opçõesServidor, error) {
+	confiáveis, err := analisarRedes(cfg.Confiáveis)
+	if err != nil {
+		return opçõesServidor{}, err
+	}
+	if cfg.Taxa > 0 && len(confiáveis) == 0 {
+		slog.Warn("limite de taxa sem proxies confiáveis: atrás de um proxy, todos os clientes dividem o mesmo limite",
+			"limite_taxa", cfg.Taxa)
+	}
+	return opçõesServidor{Taxa: cfg.Taxa, Rajada: cfg.Rajada, ProxiesConfiáveis: confiáveis,
+		ConsultaMáxima: cfg.ConsultaMáxima, Orçamento: cfg.Orçamento, URLPública: cfg.URLPública}, nil
+}
+
+// versãoCarregada identifica a UCD usada pelo servidor, para as métricas
+func versãoCarregada(cfg *configuração) string {
+	if cfg.caminhoExplícito() == "" {
+		if versão := cfg.repositório().versão; versão != "" {
+			return versão
+		}
+	}
+	return "desconhecida"
+}
+
+func executarDados(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opções.String("dados", cfg.Dados, "diretório dos arquivos de dados Unicode")
+	opçõesDeDownload(opções, cfg)
+	verificar := opções.Bool("verificar", false, "confere as somas SHA-256 registradas no manifesto")
+	baixar := opções.Bool("baixar", false, "baixa o UCD.zip se ele ainda não estiver presente")
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	repositório := cfg.repositório()
+	if *baixar {
+		if err := repositório.instalar(); err != nil {
+			return err
+		}
+	}
+	return repositório.Exibir(os.Stdout, *verificar)
+}
+
+func executarAtualizar(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opções.String("dados", cfg.Dados, "diretório dos arquivos de dados Unicode")
+	versão := opções.String("versao", "", "versão da UCD a instalar, como 15.1.0; não muda a ativa sem -ativar")
+	ativar := opções.String("ativar", "", "versão a usar nas consultas, já instalada ou instalada com -versao")
+	listar := opções.Bool("listar", false, "apenas lista as versões instaladas, sem acessar a rede")
+	opçõesDeDownload(opções, cfg)
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	raiz := cfg.repositórioRaiz()
+	switch {
+	case *listar:
+	case *versão != "" || *ativar != "":
+		if *versão != "" {
+			if err := atualizarVersão(os.Stdout, raiz.daVersão(*versão)); err != nil {
+				return err
+			}
+		}
+		if *ativar != "" {
+			if err := raiz.ativar(*ativar); err != nil {
+				return err
+			}
+		} else if raiz.versãoAtiva() != *versão {
+			fmt.Printf("versão %s instalada; para usá-la nas consultas: sinais atualizar -ativar %s\n", *versão, *versão)
+		}
+	default:
+		return atualizarSemVersão(os.Stdout, raiz)
+	}
+	return exibirVersões(os.Stdout, raiz)
+}
+
+// atualizarVersão instala a versão ou, se ela já estiver instalada, confere
+// com uma requisição condicional se o UCD.zip publicado mudou
+func atualizarVersão(w io.Writer, r repositórioDados) error {
+	if _, err := os.Stat(r.caminho(nomeZipUCD)); err != nil {
+		return r.instalar()
+	}
+	return exibirAtualização(w, r, arquivoZipUCD)
+}
+
+// atualizarSemVersão confere os arquivos sem versão presentes no diretório
+// de dados, baixando só os que mudaram; serve para verificações periódicas
+func atualizarSemVersão(w io.Writer, raiz repositórioDados) error {
+	presentes := 0
+	for _, arquivo := range append([]arquivoUCD{arquivoZipUCD}, arquivosUCD...) {
+		if _, err := os.Stat(raiz.caminho(arquivo.nome)); err != nil {
+			continue
+		}
+		presentes++
+		if err := exibirAtualização(w, raiz, arquivo); err != nil {
+			return err
+		}
+	}
+	if presentes == 0 {
+		fmt.Fprintln(w, "nenhum arquivo sem versão para atualizar (use: sinais atualizar -versao 15.1.0 -ativar 15.1.0)")
+	}
+	return nil
+}
+
+func exibirAtualização(w io.Writer, r repositórioDados, arquivo arquivoUCD) error {
+	mudou, err := r.atualizar(arquivo)
+	if err != nil {
+		return err
+	}
+	situação := "sem alterações"
+	if mudou {
+		situação = "atualizado"
+	}
+	fmt.Fprintf(w, "%s: %s\n", r.caminho(arquivo.nome), situação)
+	return nil
+}
+
+// exibirVersões lista as versões instaladas, marcando a ativa com *
+func exibirVersões(w io.Writer, raiz repositórioDados) error {
+	versões, err := raiz.versões()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "diretório: %s\n", raiz.diretório)
+	if len(versões) == 0 {
+		fmt.Fprintln(w, "nenhuma versão instalada (use: sinais atualizar -versao 15.1.0 -ativar 15.1.0)")
+	}
+	ativa := raiz.versãoAtiva()
+	for _, versão := range versões {
+		marca := " "
+		if versão == ativa {
+			marca = "*"
+		}
+		fmt.Fprintf(w, "%s %s\n", marca, versão)
+	}
+	return nil
+}
+
+// carregarVersão lê o UnicodeData.txt e o NameAliases.txt de uma versão
+// instalada; o NameAliases.txt é opcional
+func carregarVersão(raiz repositórioDados, versão string) ([]string, map[rune][]alias, error) {
+	if err := validarVersão(versão); err != nil {
+		return nil, nil, err
+	}
+	r := raiz.daVersão(versão)
+	ucd, err := r.abrirLocal("UnicodeData.txt")
+	if err != nil {
+		return nil, nil, fmt.Errorf("versão %s não instalada (use: sinais atualizar -versao %s)", versão, versão)
+	}
+	defer ucd.Close()
+	linhas := carregar(ucd)
+	aliases := map[rune][]alias{}
+	if arquivo, err := r.abrirLocal("NameAliases.txt"); err == nil {
+		aliases = carregarAliases(arquivo)
+		arquivo.Close()
+	}
+	return linhas, aliases, nil
+}
+
+func executarDiff(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoFormato(opções, cfg)
+	opções.String("dados", cfg.Dados, "diretório dos arquivos de dados Unicode")
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	if opções.NArg() != 2 {
+		opções.Usage()
+		return errors.New("informe duas versões, como: sinais diff 9.0.0 15.1.0")
+	}
+	de, para := opções.Arg(0), opções.Arg(1)
+	antigas, aliasesAntigos, err := carregarVersão(cfg.repositórioRaiz(), de)
+	if err != nil {
+		return err
+	}
+	novas, aliasesNovos, err := carregarVersão(cfg.repositórioRaiz(), para)
+	if err != nil {
+		return err
+	}
+	return Diferenciar(de, para, antigas, novas, aliasesAntigos, aliasesNovos).Exibir(os.Stdout, cfg.Formato)
+}
+
+func executarConfig(opções *flag.FlagSet, args []string) error {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return err
+	}
+	opçãoFormato(opções, cfg)
+	opções.String("dados", cfg.Dados, "diretório dos arquivos de dados Unicode")
+	opções.String("idioma", cfg.Idioma, "idioma dos sinônimos usados nas consultas")
+	opções.String("endereco", cfg.Endereço, "endereço onde o servidor HTTP escuta")
+	if err := analisarOpções(opções, cfg, args); err != nil {
+		return err
+	}
+	cfg.Exibir(os.Stdout)
+	return nil
+}
+
+func executarAjuda(opções *flag.FlagSet, args []string) error {
+	if err := opções.Parse(args); err != nil {
+		return err
+	}
+	if opções.NArg() > 0 {
+		cmd := acharComando(opções.Arg(0))
+		if cmd == nil {
+			return fmt.Errorf("comando desconhecido: %q", opções.Arg(0))
+		}
+		// executar com -h exibe a mensagem de uso com as opções do comando
+		cmd.executar(cmd.opções(os.Stdout), []string{"-h"})
+		return nil
+	}
+	fmt.Println("uso: sinais COMANDO [opções] [argumentos]")
+	fmt.Println("     sinais PALAVRA...  (o mesmo que: sinais buscar PALAVRA...; aceita só as opções de buscar)")
+	fmt.Println("     sinais -i          (o mesmo que: sinais interativo)")
+	fmt.Println("\ncomandos:")
+	for _, cmd := range comandos {
+		if !cmd.oculto {
+			fmt.Printf("  %-10s %s\n", cmd.nome, cmd.resumo)
+		}
+	}
+	fmt.Println("\nUse \"sinais ajuda COMANDO\" para ver as opções de um comando.")
+	return nil
+}
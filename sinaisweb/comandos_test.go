@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func executarComArgs(args ...string) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = append([]string{""}, args...)
+	main()
+}
+
+func Example_subcomandoBuscar() {
+	executarComArgs("buscar", "cruzeiro")
+	// Output:
+	// U+20A2	₢	CRUZEIRO SIGN
+}
+
+func Example_subcomandoInfo() {
+	executarComArgs("info", "U+2603")
+	// Output:
+	// U+2603	☃	SNOWMAN
+	//   categoria:     So
+	//   combinação:    0
+	//   bidi:          ON
+	//   UTF-8:         E2 98 83
+}
+
+func Example_subcomandoDescrever() {
+	executarComArgs("descrever", "₢☃")
+	// Output:
+	// U+20A2	₢	CRUZEIRO SIGN
+	// U+2603	☃	SNOWMAN
+}
+
+func Example_ajudaDeComando() {
+	executarComArgs("ajuda", "descrever")
+	// Output:
+	// uso: sinais descrever [opções] TEXTO...
+	//
+	// exibe o código e o nome de cada caractere do texto
+	//
+	// opções:
+	//   -versao string
+	//     	versão da UCD consultada, como 15.1.0 (padrão: a versão ativa)
+}
+
+func TestExecutar_opçãoInválida(t *testing.T) {
+	err := Executar([]string{"buscar", "-x"})
+	if err == nil {
+		t.Errorf("Executar(buscar -x) deveria devolver erro")
+	}
+}
+
+func TestExecutar_buscaImplícitaComOpçãoDesconhecida(t *testing.T) {
+	isolarConfiguração(t, "")
+	if err := Executar([]string{"-x", "cat"}); err == nil {
+		t.Errorf("Executar(-x cat) deveria devolver erro: as opções vão para o comando buscar")
+	}
+}
+
+func TestExecutar_argumentoVazio(t *testing.T) {
+	if err := Executar([]string{""}); err != nil {
+		t.Errorf("Executar(\"\"): %v", err)
+	}
+}
+
+func TestExecutar_ajudaNãoÉErro(t *testing.T) {
+	if err := Executar([]string{"info", "-h"}); err != nil {
+		t.Errorf("Executar(info -h): %v", err)
+	}
+}
+
+func TestExecutarAtualizar_instalarNãoAtiva(t *testing.T) {
+	isolarConfiguração(t, "")
+	espelho, dados := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(espelho, "15.1.0", "ucd"), 0o755)
+	criarZipUCD(t, filepath.Join(espelho, "15.1.0", "ucd", nomeZipUCD), arquivosZipDeTeste)
+	base := []string{"atualizar", "-dados", dados, "-espelhos", espelho, "-progresso", progressoSilencioso}
+	if err := Executar(append(base, "-versao", "15.1.0")); err != nil {
+		t.Fatal(err)
+	}
+	raiz := repositórioDados{diretório: dados}
+	if versões, _ := raiz.versões(); len(versões) != 1 || raiz.versãoAtiva() != "" {
+		t.Errorf("-versao deveria instalar sem ativar: versões %q, ativa %q", versões, raiz.versãoAtiva())
+	}
+	if err := Executar(append(base, "-ativar", "15.1.0")); err != nil {
+		t.Fatal(err)
+	}
+	if ativa := raiz.versãoAtiva(); ativa != "15.1.0" {
+		t.Errorf("versão ativa depois de -ativar\nesperado: %q; recebido: %q", "15.1.0", ativa)
+	}
+}
+
+func TestOpçõesDeProteção(t *testing.T) {
+	isolarConfiguração(t, "")
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Taxa != 0 {
+		t.Errorf("limite de taxa padrão\nesperado: 0; recebido: %g", cfg.Taxa)
+	}
+	anterior := slog.Default()
+	defer slog.SetDefault(anterior)
+	casos := []struct {
+		taxa       float64
+		confiáveis []string
+		aviso      bool
+	}{
+		{0, nil, false},
+		{5, nil, true},
+		{5, []string{"10.0.0.0/8"}, false},
+	}
+	for _, caso := range casos {
+		var registro bytes.Buffer
+		slog.SetDefault(novoRegistrador(&registro, "info", "texto"))
+		cfg.Taxa, cfg.Confiáveis = caso.taxa, caso.confiáveis
+		opções, err := opçõesDeProteção(cfg)
+		if err != nil {
+			t.Errorf("opçõesDeProteção(taxa %g, proxies %q): %v", caso.taxa, caso.confiáveis, err)
+			continue
+		}
+		if opções.Taxa != caso.taxa || len(opções.ProxiesConfiáveis) != len(caso.confiáveis) {
+			t.Errorf("opçõesDeProteção(taxa %g, proxies %q) -> %+v", caso.taxa, caso.confiáveis, opções)
+		}
+		if aviso := strings.Contains(registro.String(), "limite de taxa sem proxies confiáveis"); aviso != caso.aviso {
+			t.Errorf("taxa %g, proxies %q\naviso esperado: %v; registro: %q", caso.taxa, caso.confiáveis, caso.aviso, registro.String())
+		}
+	}
+}
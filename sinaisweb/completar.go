@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const scriptBash = `# completamento do sinais para bash
+# uso: source <(sinais completion bash)
+_sinais() {
+    local IFS=$'\n'
+    COMPREPLY=($(sinais __completar "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _sinais sinais
+`
+
+const scriptZsh = `#compdef sinais
+# completamento do sinais para zsh
+# uso: source <(sinais completion zsh)
+_sinais() {
+    local -a candidatos
+    candidatos=("${(@f)$(sinais __completar "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -a candidatos
+}
+compdef _sinais sinais
+`
+
+const scriptFish = `# completamento do sinais para fish
+# uso: sinais completion fish | source
+function __sinais_completar
+    set -l anteriores (commandline -opc)
+    sinais __completar $anteriores[2..-1] (commandline -ct | string collect)
+end
+complete -c sinais -f -a '(__sinais_completar)'
+`
+
+var scriptsCompletamento = map[string]string{
+	"bash": scriptBash,
+	"zsh":  scriptZsh,
+	"fish": scriptFish,
+}
+
+// Vocabulário devolve, em ordem alfabética e sem repetições, as palavras
+// que ocorrem nos nomes dos caracteres
+func Vocabulário(linhas []string) []string {
+	vistas := map[string]bool{}
+	for _, linha := range linhas {
+		_, _, palavras := AnalisarLinha(linha)
+		for _, palavra := range palavras {
+			vistas[palavra] = true
+		}
+	}
+	vocabulário := make([]string, 0, len(vistas))
+	for palavra := range vistas {
+		vocabulário = append(vocabulário, palavra)
+	}
+	sort.Strings(vocabulário)
+	return vocabulário
+}
+
+// palavrasComPrefixo busca no vocabulário ordenado as palavras que
+// começam com o prefixo
+func palavrasComPrefixo(vocabulário []string, prefixo string) []string {
+	início := sort.SearchStrings(vocabulário, prefixo)
+	fim := início
+	for fim < len(vocabulário) && strings.HasPrefix(vocabulário[fim], prefixo) {
+		fim++
+	}
+	return vocabulário[início:fim]
+}
+
+// opçõesDoComando lista as opções aceitas por um comando, sem executá-lo
+func opçõesDoComando(cmd *comando) []string {
+	opções := cmd.opções(io.Discard)
+	cmd.executar(opções, []string{"-h"})
+	nomes := []string{}
+	opções.VisitAll(func(f *flag.Flag) {
+		nomes = append(nomes, "-"+f.Name)
+	})
+	return nomes
+}
+
+func filtrarPrefixo(candidatos []string, prefixo string) []string {
+	filtrados := []string{}
+	for _, candidato := range candidatos {
+		if strings.HasPrefix(candidato, prefixo) {
+			filtrados = append(filtrados, candidato)
+		}
+	}
+	return filtrados
+}
+
+// vocabulárioLocal carrega o vocabulário sem baixar a UCD, para que o
+// completamento nunca bloqueie o shell
+func vocabulárioLocal() []string {
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		return nil
+	}
+	ucd, err := abrirUCDLocal(cfg)
+	if err != nil {
+		return nil
+	}
+	defer ucd.Close()
+	return Vocabulário(carregar(ucd))
+}
+
+// completarPalavras sugere palavras do vocabulário, na caixa digitada
+func completarPalavras(vocabulário []string, atual string) []string {
+	candidatos := palavrasComPrefixo(vocabulário, strings.ToUpper(atual))
+	minúsculas := atual != "" && strings.IndexFunc(atual, unicode.IsUpper) < 0
+	if !minúsculas {
+		return candidatos
+	}
+	convertidos := make([]string, len(candidatos))
+	for i, candidato := range candidatos {
+		convertidos[i] = strings.ToLower(candidato)
+	}
+	return convertidos
+}
+
+// Completar devolve os candidatos para a última palavra de args, que é
+// a palavra sendo digitada; as anteriores dão o contexto
+func Completar(args []string, vocabulário func() []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	atual := args[len(args)-1]
+	anteriores := args[:len(args)-1]
+	if len(anteriores) == 0 {
+		if strings.HasPrefix(atual, "-") {
+			return filtrarPrefixo([]string{"-h", "-i"}, atual)
+		}
+		nomes := []string{}
+		for _, cmd := range comandos {
+			if !cmd.oculto {
+				nomes = append(nomes, cmd.nome)
+			}
+		}
+		if atual == "" {
+			return nomes
+		}
+		return append(filtrarPrefixo(nomes, atual), completarPalavras(vocabulário(), atual)...)
+	}
+	cmd := acharComando(anteriores[0])
+	if cmd == nil || cmd.oculto {
+		cmd = acharComando("buscar")
+	}
+	anterior := anteriores[len(anteriores)-1]
+	if anterior == "-formato" || anterior == "--formato" {
+		return filtrarPrefixo(strings.Split(nomesFormatos(), ", "), atual)
+	}
+	if strings.HasPrefix(atual, "-") {
+		return filtrarPrefixo(opçõesDoComando(cmd), atual)
+	}
+	switch cmd.nome {
+	case "ajuda":
+		return Completar([]string{atual}, func() []string { return nil })
+	case "completion":
+		return filtrarPrefixo([]string{"bash", "fish", "zsh"}, atual)
+	case "buscar", "escolher":
+		return completarPalavras(vocabulário(), atual)
+	}
+	return nil
+}
+
+func executarCompletion(opções *flag.FlagSet, args []string) error {
+	if err := opções.Parse(args); err != nil {
+		return err
+	}
+	script, ok := scriptsCompletamento[opções.Arg(0)]
+	if opções.NArg() != 1 || !ok {
+		opções.Usage()
+		return fmt.Errorf("informe o shell: bash, fish ou zsh")
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// executarCompletar atende os scripts de completamento; os argumentos não
+// são interpretados como opções
+func executarCompletar(opções *flag.FlagSet, args []string) error {
+	for _, candidato := range Completar(args, vocabulárioLocal) {
+		fmt.Println(candidato)
+	}
+	return nil
+}
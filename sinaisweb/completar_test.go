@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func vocabulárioDeTeste() []string {
+	return Vocabulário(carregar(strings.NewReader(linhas3Da43)))
+}
+
+func TestVocabulário(t *testing.T) {
+	esperado := []string{"A", "AT", "B", "C", "CAPITAL", "COMMERCIAL", "EQUALS",
+		"GREATER", "LATIN", "LETTER", "MARK", "QUESTION", "SIGN", "THAN"}
+	if obtido := vocabulárioDeTeste(); !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("Vocabulário\nesperado: %q\nrecebido: %q", esperado, obtido)
+	}
+}
+
+func TestCompletar(t *testing.T) {
+	casos := []struct {
+		args     []string
+		esperado []string
+	}{
+		{[]string{"bu"}, []string{"buscar"}},
+		{[]string{"c"}, []string{"config", "completion", "c", "capital", "commercial"}},
+		{[]string{"-"}, []string{"-h", "-i"}},
+		{[]string{"buscar", "LE"}, []string{"LETTER"}},
+		{[]string{"buscar", "latin", "le"}, []string{"letter"}},
+		{[]string{"LATIN", "CA"}, []string{"CAPITAL"}},
+		{[]string{"buscar", "-f"}, []string{"-formato"}},
+		{[]string{"buscar", "-formato", "j"}, []string{"json"}},
+		{[]string{"completion", ""}, []string{"bash", "fish", "zsh"}},
+		{[]string{"ajuda", "se"}, []string{"servir"}},
+		{[]string{"info", "U+"}, nil},
+	}
+	for _, caso := range casos {
+		obtido := Completar(caso.args, vocabulárioDeTeste)
+		if len(obtido) != len(caso.esperado) || (len(obtido) > 0 && !reflect.DeepEqual(obtido, caso.esperado)) {
+			t.Errorf("Completar(%q)\nesperado: %q; recebido: %q", caso.args, caso.esperado, obtido)
+		}
+	}
+}
+
+func Example_completion() {
+	executarComArgs("completion", "bash")
+	// Output:
+	// # completamento do sinais para bash
+	// # uso: source <(sinais completion bash)
+	// _sinais() {
+	//     local IFS=$'\n'
+	//     COMPREPLY=($(sinais __completar "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+	// }
+	// complete -o default -F _sinais sinais
+}
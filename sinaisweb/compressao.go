@@ -0,0 +1,104 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressor é o que gzip.Writer e zlib.Writer têm em comum
+type compressor interface {
+	io.WriteCloser
+	Reset(io.Writer)
+}
+
+// compressores reaproveita os escritores, cujo estado interno é grande
+var compressores = map[string]*sync.Pool{
+	"gzip":    {New: func() interface{} { return gzip.NewWriter(io.Discard) }},
+	"deflate": {New: func() interface{} { return zlib.NewWriter(io.Discard) }},
+}
+
+// escolherCodificação interpreta o Accept-Encoding e devolve gzip,
+// deflate ou "" para enviar sem compressão; em empate, gzip
+func escolherCodificação(aceitas string) string {
+	escolhida, melhor := "", 0.0
+	for _, item := range strings.Split(aceitas, ",") {
+		nome, parâmetros, _ := strings.Cut(strings.TrimSpace(item), ";")
+		nome = strings.ToLower(strings.TrimSpace(nome))
+		if _, ok := compressores[nome]; !ok {
+			continue
+		}
+		q := 1.0
+		if valor, ok := strings.CutPrefix(strings.TrimSpace(parâmetros), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(valor, 64); err != nil {
+				continue
+			}
+		}
+		if q > melhor || (q == melhor && q > 0 && nome == "gzip") {
+			escolhida, melhor = nome, q
+		}
+	}
+	return escolhida
+}
+
+// gravadorComprimido comprime o corpo quando a resposta tem um; a decisão
+// é tomada no WriteHeader, quando o status é conhecido
+type gravadorComprimido struct {
+	http.ResponseWriter
+	codificação string
+	compressor  compressor
+	decidiu     bool
+}
+
+func (g *gravadorComprimido) WriteHeader(status int) {
+	if !g.decidiu {
+		g.decidiu = true
+		temCorpo := status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+		if temCorpo && g.Header().Get("Content-Encoding") == "" {
+			g.Header().Set("Content-Encoding", g.codificação)
+			g.Header().Del("Content-Length")
+			g.compressor = compressores[g.codificação].Get().(compressor)
+			g.compressor.Reset(g.ResponseWriter)
+		}
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gravadorComprimido) Write(p []byte) (int, error) {
+	if !g.decidiu {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.compressor != nil {
+		return g.compressor.Write(p)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+func (g *gravadorComprimido) Unwrap() http.ResponseWriter { return g.ResponseWriter }
+
+func (g *gravadorComprimido) fechar() {
+	if g.compressor != nil {
+		g.compressor.Close()
+		compressores[g.codificação].Put(g.compressor)
+	}
+}
+
+// comprimir negocia gzip ou deflate com o cliente pelo Accept-Encoding
+func comprimir(próximo http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		codificação := escolherCodificação(r.Header.Get("Accept-Encoding"))
+		if codificação == "" {
+			próximo.ServeHTTP(w, r)
+			return
+		}
+		gravador := &gravadorComprimido{ResponseWriter: w, codificação: codificação}
+		defer gravador.fechar()
+		próximo.ServeHTTP(gravador, r)
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEscolherCodificação(t *testing.T) {
+	casos := []struct {
+		aceitas  string
+		esperado string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip, deflate, br", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"br, identity", ""},
+		{"GZIP", "gzip"},
+		{"gzip;q=x, deflate;q=0.1", "deflate"},
+	}
+	for _, caso := range casos {
+		if obtido := escolherCodificação(caso.aceitas); obtido != caso.esperado {
+			t.Errorf("escolherCodificação(%q)\nesperado: %q; recebido: %q", caso.aceitas, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestComprimir(t *testing.T) {
+	corpo := strings.Repeat("LATIN CAPITAL LETTER A\n", 100)
+	tratador := comprimir(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/vazia" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, corpo)
+	}))
+	descompressores := map[string]func(io.Reader) (io.Reader, error){
+		"":        func(r io.Reader) (io.Reader, error) { return r, nil },
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	}
+	for _, aceitas := range []string{"", "gzip", "deflate", "gzip", "deflate"} {
+		requisição := httptest.NewRequest("GET", "/", nil)
+		requisição.Header.Set("Accept-Encoding", aceitas)
+		gravador := httptest.NewRecorder()
+		tratador.ServeHTTP(gravador, requisição)
+		codificação := gravador.Header().Get("Content-Encoding")
+		if codificação != aceitas || gravador.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: cabeçalhos %v", aceitas, gravador.Header())
+			continue
+		}
+		leitor, err := descompressores[codificação](gravador.Body)
+		if err != nil {
+			t.Errorf("Accept-Encoding %q: %v", aceitas, err)
+			continue
+		}
+		if obtido, _ := io.ReadAll(leitor); string(obtido) != corpo {
+			t.Errorf("Accept-Encoding %q: corpo descomprimido diferente (%d bytes)", aceitas, len(obtido))
+		}
+	}
+
+	requisição := httptest.NewRequest("GET", "/vazia", nil)
+	requisição.Header.Set("Accept-Encoding", "gzip")
+	gravador := httptest.NewRecorder()
+	tratador.ServeHTTP(gravador, requisição)
+	if gravador.Header().Get("Content-Encoding") != "" || gravador.Body.Len() != 0 {
+		t.Errorf("304 não deveria ser comprimido: %v %q", gravador.Header(), gravador.Body.String())
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// origens possíveis de um valor de configuração, da menor para a maior
+// precedência
+const (
+	origemPadrão   = "padrão"
+	origemArquivo  = "arquivo"
+	origemAmbiente = "ambiente"
+	origemOpção    = "opção"
+)
+
+// configuração reúne os valores que o usuário pode definir no arquivo de
+// configuração, em variáveis de ambiente ou em opções da linha de comando
+type configuração struct {
+	Formato        string
+	Dados          string
+	Idioma         string
+	Extras         []string
+	Endereço       string
+	Tentativas     int
+	TempoLimite    time.Duration
+	Progresso      string
+	Versão         string // vazia para usar a versão ativa do diretório de dados
+	Espelhos       []string
+	Proxy          string
+	CA             string
+	Inseguro       bool
+	PáginaMáxima   int
+	NívelLog       string
+	FormatoLog     string
+	Cache          int
+	Taxa           float64
+	Rajada         int
+	Confiáveis     []string // proxies cujo X-Forwarded-For é aceito
+	ConsultaMáxima int
+	Orçamento      time.Duration
+	URLPública     string                       // vazia para reconstruir o endereço de cada requisição
+	Sinônimos      map[string]map[string]string // idioma -> palavra -> substituto
+	caminho        string
+	origens        map[string]string
+}
+
+// chavesConfiguração lista as chaves aceitas e a variável de ambiente
+// correspondente a cada uma
+var chavesConfiguração = []struct{ chave, variável string }{
+	{"formato", "SINAIS_FORMATO"},
+	{"dados", "SINAIS_DADOS"},
+	{"idioma", "SINAIS_IDIOMA"},
+	{"extras", "SINAIS_EXTRAS"},
+	{"endereco", "SINAIS_ENDERECO"},
+	{"tentativas", "SINAIS_TENTATIVAS"},
+	{"tempo-limite", "SINAIS_TEMPO_LIMITE"},
+	{"progresso", "SINAIS_PROGRESSO"},
+	{"versao", "SINAIS_VERSAO"},
+	{"espelhos", "SINAIS_ESPELHOS"},
+	{"proxy", "SINAIS_PROXY"},
+	{"ca", "SINAIS_CA"},
+	{"inseguro", "SINAIS_INSEGURO"},
+	{"pagina-maxima", "SINAIS_PAGINA_MAXIMA"},
+	{"log-nivel", "SINAIS_LOG_NIVEL"},
+	{"log-formato", "SINAIS_LOG_FORMATO"},
+	{"cache", "SINAIS_CACHE"},
+	{"limite-taxa", "SINAIS_LIMITE_TAXA"},
+	{"limite-rajada", "SINAIS_LIMITE_RAJADA"},
+	{"proxies-confiaveis", "SINAIS_PROXIES_CONFIAVEIS"},
+	{"consulta-maxima", "SINAIS_CONSULTA_MAXIMA"},
+	{"orcamento", "SINAIS_ORCAMENTO"},
+	{"url-publica", "SINAIS_URL_PUBLICA"},
+}
+
+// caminhoConfiguração segue a especificação XDG; SINAIS_CONFIG tem precedência
+func caminhoConfiguração() (string, error) {
+	if caminho := os.Getenv("SINAIS_CONFIG"); caminho != "" {
+		return caminho, nil
+	}
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "sinais", "config"), nil
+}
+
+func configuraçãoPadrão() *configuração {
+	cfg := &configuração{
+		Formato:        "texto",
+		Dados:          diretórioDadosPadrão(),
+		Idioma:         "pt",
+		Extras:         []string{},
+		Endereço:       endereçoPadrão,
+		Tentativas:     tentativasPadrão,
+		TempoLimite:    tempoLimitePadrão,
+		Progresso:      progressoAuto,
+		Espelhos:       espelhosPadrão,
+		PáginaMáxima:   páginaMáximaPadrão,
+		NívelLog:       "info",
+		FormatoLog:     "texto",
+		Cache:          cacheResultadosPadrão,
+		Taxa:           taxaPadrão,
+		Rajada:         rajadaPadrão,
+		Confiáveis:     []string{},
+		ConsultaMáxima: consultaMáximaPadrão,
+		Orçamento:      orçamentoPadrão,
+		Sinônimos:      map[string]map[string]string{},
+		origens:        map[string]string{},
+	}
+	for _, c := range chavesConfiguração {
+		cfg.origens[c.chave] = origemPadrão
+	}
+	return cfg
+}
+
+func expandirCaminho(caminho string) string {
+	if strings.HasPrefix(caminho, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, caminho[2:])
+		}
+	}
+	return caminho
+}
+
+func separarLista(valor string, separador string) []string {
+	itens := []string{}
+	for _, item := range strings.Split(valor, separador) {
+		if item = strings.TrimSpace(item); item != "" {
+			itens = append(itens, expandirCaminho(item))
+		}
+	}
+	return itens
+}
+
+// definir atribui um valor à chave, registrando a origem
+func (cfg *configuração) definir(chave, valor, origem string) error {
+	switch chave {
+	case "formato":
+		if _, ok := formatadores[valor]; !ok {
+			return fmt.Errorf("formato desconhecido: %q (use: %s)", valor, nomesFormatos())
+		}
+		cfg.Formato = valor
+	case "dados":
+		cfg.Dados = expandirCaminho(valor)
+	case "idioma":
+		cfg.Idioma = strings.ToLower(valor)
+	case "extras":
+		separador := ","
+		if origem == origemAmbiente {
+			separador = string(os.PathListSeparator)
+		}
+		cfg.Extras = separarLista(valor, separador)
+	case "endereco":
+		cfg.Endereço = valor
+	case "tentativas":
+		tentativas, err := strconv.Atoi(valor)
+		if err != nil || tentativas < 1 {
+			return fmt.Errorf("tentativas deve ser um inteiro positivo: %q", valor)
+		}
+		cfg.Tentativas = tentativas
+	case "tempo-limite":
+		limite, err := time.ParseDuration(valor)
+		if err != nil || limite <= 0 {
+			return fmt.Errorf("tempo-limite deve ser uma duração como 30s ou 2m: %q", valor)
+		}
+		cfg.TempoLimite = limite
+	case "progresso":
+		if !contém(modosProgresso, valor) {
+			return fmt.Errorf("progresso desconhecido: %q (use: %s)", valor, strings.Join(modosProgresso, ", "))
+		}
+		cfg.Progresso = valor
+	case "versao":
+		if valor != "" {
+			if err := validarVersão(valor); err != nil {
+				return err
+			}
+		}
+		cfg.Versão = valor
+	case "espelhos":
+		espelhos := separarLista(valor, ",")
+		if len(espelhos) == 0 {
+			return fmt.Errorf("informe ao menos um espelho")
+		}
+		cfg.Espelhos = espelhos
+	case "proxy":
+		cfg.Proxy = valor
+	case "ca":
+		cfg.CA = expandirCaminho(valor)
+	case "inseguro":
+		inseguro, err := strconv.ParseBool(valor)
+		if err != nil {
+			return fmt.Errorf("inseguro deve ser true ou false: %q", valor)
+		}
+		cfg.Inseguro = inseguro
+	case "pagina-maxima":
+		máxima, err := strconv.Atoi(valor)
+		if err != nil || máxima < 1 {
+			return fmt.Errorf("pagina-maxima deve ser um inteiro positivo: %q", valor)
+		}
+		cfg.PáginaMáxima = máxima
+	case "log-nivel":
+		if _, ok := níveisLog[valor]; !ok {
+			return fmt.Errorf("log-nivel desconhecido: %q (use: %s)", valor, nomesNíveisLog())
+		}
+		cfg.NívelLog = valor
+	case "log-formato":
+		if !contém(formatosLog, valor) {
+			return fmt.Errorf("log-formato desconhecido: %q (use: %s)", valor, strings.Join(formatosLog, ", "))
+		}
+		cfg.FormatoLog = valor
+	case "cache":
+		capacidade, err := strconv.Atoi(valor)
+		if err != nil || capacidade < 0 {
+			return fmt.Errorf("cache deve ser um inteiro maior ou igual a 0: %q", valor)
+		}
+		cfg.Cache = capacidade
+	case "limite-taxa":
+		taxa, err := strconv.ParseFloat(valor, 64)
+		if err != nil || taxa < 0 {
+			return fmt.Errorf("limite-taxa deve ser um número maior ou igual a 0: %q", valor)
+		}
+		cfg.Taxa = taxa
+	case "limite-rajada":
+		rajada, err := strconv.Atoi(valor)
+		if err != nil || rajada < 1 {
+			return fmt.Errorf("limite-rajada deve ser um inteiro positivo: %q", valor)
+		}
+		cfg.Rajada = rajada
+	case "proxies-confiaveis":
+		confiáveis := separarLista(valor, ",")
+		if _, err := analisarRedes(confiáveis); err != nil {
+			return fmt.Errorf("proxies-confiaveis: %w", err)
+		}
+		cfg.Confiáveis = confiáveis
+	case "consulta-maxima":
+		máxima, err := strconv.Atoi(valor)
+		if err != nil || máxima < 0 {
+			return fmt.Errorf("consulta-maxima deve ser um inteiro maior ou igual a 0: %q", valor)
+		}
+		cfg.ConsultaMáxima = máxima
+	case "orcamento":
+		orçamento, err := time.ParseDuration(valor)
+		if err != nil || orçamento < 0 {
+			return fmt.Errorf("orcamento deve ser uma duração como 2s ou 500ms: %q", valor)
+		}
+		cfg.Orçamento = orçamento
+	case "url-publica":
+		endereço, err := url.Parse(valor)
+		if valor != "" && (err != nil || (endereço.Scheme != "http" && endereço.Scheme != "https") || endereço.Host == "") {
+			return fmt.Errorf("url-publica deve ser uma URL http ou https, como https://sinais.exemplo.org: %q", valor)
+		}
+		cfg.URLPública = strings.TrimSuffix(valor, "/")
+	default:
+		return fmt.Errorf("chave desconhecida: %q", chave)
+	}
+	cfg.origens[chave] = origem
+	return nil
+}
+
+// lerArquivoConfiguração interpreta linhas "chave = valor"; seções
+// [sinonimos] e [sinonimos.IDIOMA] definem sinônimos para as consultas
+func (cfg *configuração) lerArquivoConfiguração(arquivo io.Reader) error {
+	seção := ""
+	varredor := bufio.NewScanner(arquivo)
+	for número := 1; varredor.Scan(); número++ {
+		linha := strings.TrimSpace(varredor.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+		if strings.HasPrefix(linha, "[") && strings.HasSuffix(linha, "]") {
+			seção = strings.TrimSpace(linha[1 : len(linha)-1])
+			if seção != "sinonimos" && !strings.HasPrefix(seção, "sinonimos.") {
+				return fmt.Errorf("%s:%d: seção desconhecida: [%s]", cfg.caminho, número, seção)
+			}
+			continue
+		}
+		partes := strings.SplitN(linha, "=", 2)
+		if len(partes) != 2 {
+			return fmt.Errorf("%s:%d: esperado \"chave = valor\"", cfg.caminho, número)
+		}
+		chave, valor := strings.TrimSpace(partes[0]), strings.TrimSpace(partes[1])
+		if seção == "" {
+			if err := cfg.definir(chave, valor, origemArquivo); err != nil {
+				return fmt.Errorf("%s:%d: %v", cfg.caminho, número, err)
+			}
+			continue
+		}
+		idioma := strings.TrimPrefix(strings.TrimPrefix(seção, "sinonimos"), ".")
+		if cfg.Sinônimos[idioma] == nil {
+			cfg.Sinônimos[idioma] = map[string]string{}
+		}
+		cfg.Sinônimos[idioma][strings.ToUpper(chave)] = strings.ToUpper(valor)
+	}
+	return varredor.Err()
+}
+
+// idiomaDoAmbiente extrai o idioma de LC_ALL ou LANG, como "pt" de "pt_BR.UTF-8"
+func idiomaDoAmbiente() string {
+	for _, variável := range []string{"LC_ALL", "LANG"} {
+		valor := os.Getenv(variável)
+		if valor == "" || valor == "C" || valor == "POSIX" || strings.HasPrefix(valor, "C.") {
+			continue
+		}
+		return strings.ToLower(strings.FieldsFunc(valor, func(c rune) bool {
+			return c == '_' || c == '.' || c == '@'
+		})[0])
+	}
+	return ""
+}
+
+// carregarConfiguração combina os valores padrão, o arquivo de configuração
+// e as variáveis de ambiente, nesta ordem de precedência crescente. As
+// opções da linha de comando usam estes valores como padrão.
+func carregarConfiguração() (*configuração, error) {
+	cfg := configuraçãoPadrão()
+	caminho, err := caminhoConfiguração()
+	if err != nil {
+		return nil, err
+	}
+	cfg.caminho = caminho
+	arquivo, err := os.Open(caminho)
+	if err == nil {
+		defer arquivo.Close()
+		if err := cfg.lerArquivoConfiguração(arquivo); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if idioma := idiomaDoAmbiente(); idioma != "" && cfg.origens["idioma"] == origemPadrão {
+		cfg.definir("idioma", idioma, origemAmbiente)
+	}
+	for _, c := range chavesConfiguração {
+		if valor, ok := os.LookupEnv(c.variável); ok && valor != "" {
+			if err := cfg.definir(c.chave, valor, origemAmbiente); err != nil {
+				return nil, fmt.Errorf("%s: %v", c.variável, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// aplicarOpções registra os valores das opções que o usuário informou
+// explicitamente e cujo nome é uma chave de configuração
+func (cfg *configuração) aplicarOpções(opções *flag.FlagSet) error {
+	var erro error
+	opções.Visit(func(f *flag.Flag) {
+		if _, ok := cfg.origens[f.Name]; ok && erro == nil {
+			erro = cfg.definir(f.Name, f.Value.String(), origemOpção)
+		}
+	})
+	return erro
+}
+
+// repositórioRaiz devolve o diretório de dados gerenciado, sem escolher versão
+func (cfg *configuração) repositórioRaiz() repositórioDados {
+	return repositórioDados{diretório: cfg.Dados, download: cfg.opçõesDownload()}
+}
+
+// repositório devolve o diretório da versão configurada ou, na falta dela,
+// da versão ativa; sem nenhuma das duas, usa os arquivos sem versão
+func (cfg *configuração) repositório() repositórioDados {
+	raiz := cfg.repositórioRaiz()
+	versão := cfg.Versão
+	if versão == "" {
+		versão = raiz.versãoAtiva()
+	}
+	if versão == "" {
+		return raiz
+	}
+	return raiz.daVersão(versão)
+}
+
+// opçõesDownload devolve os ajustes de download definidos pelo usuário
+func (cfg *configuração) opçõesDownload() opçõesDownload {
+	return opçõesDownload{Tentativas: cfg.Tentativas, TempoLimite: cfg.TempoLimite,
+		Progresso: cfg.Progresso, Espelhos: cfg.Espelhos, Proxy: cfg.Proxy, CA: cfg.CA, Inseguro: cfg.Inseguro}
+}
+
+// caminhoExplícito devolve o caminho em UCD_PATH, que tem precedência sobre
+// o diretório de dados exceto quando uma versão foi pedida
+func (cfg *configuração) caminhoExplícito() string {
+	if cfg.Versão != "" {
+		return ""
+	}
+	return os.Getenv("UCD_PATH")
+}
+
+// caminhoUCD devolve o caminho do UnicodeData.txt
+func (cfg *configuração) caminhoUCD() string {
+	if caminho := cfg.caminhoExplícito(); caminho != "" {
+		return caminho
+	}
+	return cfg.repositório().caminho("UnicodeData.txt")
+}
+
+// sinônimosAtivos devolve os sinônimos gerais mais os do idioma configurado
+func (cfg *configuração) sinônimosAtivos() map[string]string {
+	ativos := map[string]string{}
+	for palavra, substituto := range cfg.Sinônimos[""] {
+		ativos[palavra] = substituto
+	}
+	for palavra, substituto := range cfg.Sinônimos[cfg.Idioma] {
+		ativos[palavra] = substituto
+	}
+	return ativos
+}
+
+// ExpandirSinônimos troca as palavras da consulta por seus sinônimos
+func ExpandirSinônimos(consulta string, sinônimos map[string]string) string {
+	termos := separar(consulta)
+	for i, termo := range termos {
+		if substituto, ok := sinônimos[termo]; ok {
+			termos[i] = substituto
+		}
+	}
+	return strings.Join(termos, " ")
+}
+
+// Exibir escreve a configuração efetiva, indicando a origem de cada valor
+func (cfg *configuração) Exibir(w io.Writer) {
+	situação := ""
+	if _, err := os.Stat(cfg.caminho); err != nil {
+		situação = " (não encontrado)"
+	}
+	fmt.Fprintf(w, "# arquivo: %s%s\n", cfg.caminho, situação)
+	valores := map[string]string{
+		"formato":            cfg.Formato,
+		"dados":              cfg.Dados,
+		"idioma":             cfg.Idioma,
+		"extras":             strings.Join(cfg.Extras, ", "),
+		"endereco":           cfg.Endereço,
+		"tentativas":         strconv.Itoa(cfg.Tentativas),
+		"tempo-limite":       cfg.TempoLimite.String(),
+		"progresso":          cfg.Progresso,
+		"versao":             cfg.Versão,
+		"espelhos":           strings.Join(cfg.Espelhos, ", "),
+		"proxy":              cfg.Proxy,
+		"ca":                 cfg.CA,
+		"inseguro":           strconv.FormatBool(cfg.Inseguro),
+		"pagina-maxima":      strconv.Itoa(cfg.PáginaMáxima),
+		"log-nivel":          cfg.NívelLog,
+		"log-formato":        cfg.FormatoLog,
+		"cache":              strconv.Itoa(cfg.Cache),
+		"limite-taxa":        strconv.FormatFloat(cfg.Taxa, 'g', -1, 64),
+		"limite-rajada":      strconv.Itoa(cfg.Rajada),
+		"proxies-confiaveis": strings.Join(cfg.Confiáveis, ", "),
+		"consulta-maxima":    strconv.Itoa(cfg.ConsultaMáxima),
+		"orcamento":          cfg.Orçamento.String(),
+		"url-publica":        cfg.URLPública,
+	}
+	for _, c := range chavesConfiguração {
+		fmt.Fprintf(w, "%-18s = %-30s # %s\n", c.chave, valores[c.chave], cfg.origens[c.chave])
+	}
+	ativos := cfg.sinônimosAtivos()
+	if len(ativos) == 0 {
+		return
+	}
+	palavras := make([]string, 0, len(ativos))
+	for palavra := range ativos {
+		palavras = append(palavras, palavra)
+	}
+	sort.Strings(palavras)
+	fmt.Fprintf(w, "\n[sinonimos.%s]\n", cfg.Idioma)
+	for _, palavra := range palavras {
+		fmt.Fprintf(w, "%s = %s\n", palavra, ativos[palavra])
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const configDeTeste = `
+# preferências
+formato = json
+dados = /srv/unicode
+extras = /a.txt, /b.txt
+
+[sinonimos]
+gato = cat
+
+[sinonimos.pt]
+coração = heart
+
+[sinonimos.es]
+corazón = heart
+`
+
+// isolarConfiguração aponta a configuração para um arquivo temporário,
+// limpa as variáveis de ambiente que a afetam e troca os dados do usuário
+// por uma UCD de teste, para que nenhum teste leia ou baixe dados reais
+func isolarConfiguração(t *testing.T, conteúdo string) {
+	dir := t.TempDir()
+	caminho := filepath.Join(dir, "config")
+	if err := os.WriteFile(caminho, []byte(conteúdo), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ucd := filepath.Join(dir, "UnicodeData.txt")
+	if err := os.WriteFile(ucd, []byte(linhas3Da43), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SINAIS_CONFIG", caminho)
+	for _, c := range chavesConfiguração {
+		t.Setenv(c.variável, "")
+	}
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "")
+	t.Setenv("UCD_PATH", ucd)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "dados"))
+	t.Setenv("HOME", dir)
+}
+
+func TestCarregarConfiguração_arquivo(t *testing.T) {
+	isolarConfiguração(t, configDeTeste)
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Formato != "json" || cfg.Dados != "/srv/unicode" || cfg.Idioma != "pt" {
+		t.Errorf("carregarConfiguração -> %#v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Extras, []string{"/a.txt", "/b.txt"}) {
+		t.Errorf("extras\nesperado: %q; recebido: %q", []string{"/a.txt", "/b.txt"}, cfg.Extras)
+	}
+	esperado := map[string]string{"GATO": "CAT", "CORAÇÃO": "HEART"}
+	if obtido := cfg.sinônimosAtivos(); !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("sinônimosAtivos\nesperado: %q; recebido: %q", esperado, obtido)
+	}
+	if cfg.origens["formato"] != origemArquivo || cfg.origens["endereco"] != origemPadrão {
+		t.Errorf("origens: %v", cfg.origens)
+	}
+}
+
+func TestCarregarConfiguração_precedência(t *testing.T) {
+	isolarConfiguração(t, configDeTeste)
+	t.Setenv("SINAIS_FORMATO", "texto")
+	t.Setenv("LANG", "es_ES.UTF-8")
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Formato != "texto" || cfg.origens["formato"] != origemAmbiente {
+		t.Errorf("ambiente deveria ter precedência sobre o arquivo: %q (%s)", cfg.Formato, cfg.origens["formato"])
+	}
+	if cfg.Idioma != "es" {
+		t.Errorf("idioma de LANG\nesperado: %q; recebido: %q", "es", cfg.Idioma)
+	}
+	opções := flag.NewFlagSet("teste", flag.ContinueOnError)
+	opçãoFormato(opções, cfg)
+	if err := analisarOpções(opções, cfg, []string{"-formato", "caractere"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Formato != "caractere" || cfg.origens["formato"] != origemOpção {
+		t.Errorf("opção deveria ter precedência sobre o ambiente: %q (%s)", cfg.Formato, cfg.origens["formato"])
+	}
+}
+
+func TestCarregarConfiguração_erros(t *testing.T) {
+	casos := []string{
+		"formato = xml",
+		"cor = azul",
+		"[cores]",
+		"sem sinal de igual",
+		"tentativas = 0",
+		"tempo-limite = 30",
+		"progresso = pontinhos",
+		"versao = 15.1",
+		"espelhos = ,",
+		"inseguro = talvez",
+		"log-nivel = verboso",
+		"log-formato = xml",
+		"cache = -1",
+		"limite-taxa = rápido",
+		"limite-rajada = 0",
+		"proxies-confiaveis = 10.0.0.0/33",
+		"consulta-maxima = -5",
+		"orcamento = logo",
+		"url-publica = sinais.exemplo.org",
+		"url-publica = ftp://sinais.exemplo.org",
+	}
+	for _, conteúdo := range casos {
+		isolarConfiguração(t, conteúdo)
+		if _, err := carregarConfiguração(); err == nil {
+			t.Errorf("carregarConfiguração(%q) deveria devolver erro", conteúdo)
+		}
+	}
+}
+
+func TestExpandirSinônimos(t *testing.T) {
+	sinônimos := map[string]string{"GATO": "CAT", "SORRINDO": "SMILING"}
+	if obtido := ExpandirSinônimos("GATO SORRINDO FACE", sinônimos); obtido != "CAT SMILING FACE" {
+		t.Errorf("ExpandirSinônimos\nesperado: %q; recebido: %q", "CAT SMILING FACE", obtido)
+	}
+}
+
+func TestCarregarUCD_extras(t *testing.T) {
+	extra := filepath.Join(t.TempDir(), "extras.txt")
+	os.WriteFile(extra, []byte("# meus caracteres\n\nF8FF;SINAIS PRIVATE LOGO;Co;0;L;;;;;N;;;;;\n"), 0o600)
+	isolarConfiguração(t, "extras = "+extra)
+	cfg, _ := carregarConfiguração()
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obtido := Listar(linhas, "SINAIS"); !strings.Contains(obtido, "SINAIS PRIVATE LOGO") {
+		t.Errorf("arquivo extra não foi carregado: %q", obtido)
+	}
+
+	os.WriteFile(extra, []byte("# meus caracteres\nF8FF;SINAIS PRIVATE LOGO;Co\n"), 0o600)
+	_, err = carregarUCD(cfg)
+	if esperado := extra + ":2:"; err == nil || !strings.HasPrefix(err.Error(), esperado) {
+		t.Errorf("linha malformada\nesperado: erro iniciado por %q; recebido: %v", esperado, err)
+	}
+}
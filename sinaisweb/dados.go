@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nomeManifesto   = "manifesto.json"
+	nomeVersãoAtiva = "versao-ativa"
+)
+
+var padrãoNúmeroVersão = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// validarVersão aceita números de versão completos, como 15.1.0
+func validarVersão(versão string) error {
+	if !padrãoNúmeroVersão.MatchString(versão) {
+		return fmt.Errorf("versão inválida: %q (use o formato 15.1.0)", versão)
+	}
+	return nil
+}
+
+// compararVersões devolve um número negativo, zero ou positivo conforme a
+// versão a seja anterior, igual ou posterior à versão b
+func compararVersões(a, b string) int {
+	partesA, partesB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partesA) && i < len(partesB); i++ {
+		na, _ := strconv.Atoi(partesA[i])
+		nb, _ := strconv.Atoi(partesB[i])
+		if na != nb {
+			return na - nb
+		}
+	}
+	return len(partesA) - len(partesB)
+}
+
+// arquivoUCD descreve um arquivo de dados Unicode gerenciado pelo sinais
+type arquivoUCD struct {
+	nome   string // nome no diretório de dados
+	remoto string // caminho relativo ao diretório ucd/ de um espelho
+}
+
+var arquivosUCD = []arquivoUCD{
+	{"UnicodeData.txt", "UnicodeData.txt"},
+	{"Blocks.txt", "Blocks.txt"},
+	{"Scripts.txt", "Scripts.txt"},
+	{"NameAliases.txt", "NameAliases.txt"},
+	{"emoji-data.txt", "emoji/emoji-data.txt"},
+}
+
+func acharArquivoUCD(nome string) (arquivoUCD, bool) {
+	for _, arquivo := range arquivosUCD {
+		if arquivo.nome == nome {
+			return arquivo, true
+		}
+	}
+	return arquivoUCD{}, false
+}
+
+// entradaManifesto registra a origem e a integridade de um arquivo baixado
+type entradaManifesto struct {
+	Versão            string    `json:"versao,omitempty"`
+	SHA256            string    `json:"sha256"`
+	Tamanho           int64     `json:"tamanho"`
+	Origem            string    `json:"origem,omitempty"`
+	Data              time.Time `json:"data"`
+	ETag              string    `json:"etag,omitempty"`
+	ÚltimaModificação string    `json:"ultima_modificacao,omitempty"`
+}
+
+type manifesto struct {
+	Arquivos map[string]entradaManifesto `json:"arquivos"`
+}
+
+// repositórioDados é o diretório gerenciado que guarda os arquivos Unicode
+// e o manifesto com suas versões e somas de verificação. Cada versão da UCD
+// instalada por "sinais atualizar" fica em um subdiretório com seu número;
+// arquivos sem versão ficam diretamente no diretório de dados.
+type repositórioDados struct {
+	diretório string
+	versão    string // vazia para os arquivos sem versão
+	download  opçõesDownload
+}
+
+// diretórioDadosPadrão segue a especificação XDG para dados do usuário
+func diretórioDadosPadrão() string {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "sinais"
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(base, "sinais")
+}
+
+func (r repositórioDados) caminho(nome string) string {
+	return filepath.Join(r.diretório, nome)
+}
+
+// daVersão devolve o repositório da versão, dentro do diretório de dados
+func (r repositórioDados) daVersão(versão string) repositórioDados {
+	r.diretório = filepath.Join(r.diretório, versão)
+	r.versão = versão
+	return r
+}
+
+// relativo devolve o caminho do arquivo da versão do repositório em um
+// espelho; os arquivos sem versão vêm da versão mais recente
+func (r repositórioDados) relativo(arquivo arquivoUCD) string {
+	if r.versão == "" {
+		return "UCD/latest/ucd/" + arquivo.remoto
+	}
+	return r.versão + "/ucd/" + arquivo.remoto
+}
+
+// versões lista as versões instaladas, da mais recente para a mais antiga
+func (r repositórioDados) versões() ([]string, error) {
+	entradas, err := os.ReadDir(r.diretório)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	versões := []string{}
+	for _, entrada := range entradas {
+		if entrada.IsDir() && validarVersão(entrada.Name()) == nil {
+			versões = append(versões, entrada.Name())
+		}
+	}
+	sort.Slice(versões, func(i, j int) bool { return compararVersões(versões[i], versões[j]) > 0 })
+	return versões, nil
+}
+
+// versãoAtiva devolve a versão escolhida com "sinais atualizar -ativar",
+// ou uma string vazia se nenhuma foi escolhida
+func (r repositórioDados) versãoAtiva() string {
+	conteúdo, err := os.ReadFile(r.caminho(nomeVersãoAtiva))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(conteúdo))
+}
+
+// ativar torna a versão instalada a usada pelas consultas
+func (r repositórioDados) ativar(versão string) error {
+	if err := validarVersão(versão); err != nil {
+		return err
+	}
+	ucd, err := r.daVersão(versão).abrirLocal("UnicodeData.txt")
+	if err != nil {
+		return fmt.Errorf("versão %s não instalada (use: sinais atualizar -versao %s -ativar %s)", versão, versão, versão)
+	}
+	ucd.Close()
+	return os.WriteFile(r.caminho(nomeVersãoAtiva), []byte(versão+"\n"), 0o644)
+}
+
+func (r repositórioDados) lerManifesto() (manifesto, error) {
+	m := manifesto{Arquivos: map[string]entradaManifesto{}}
+	conteúdo, err := os.ReadFile(r.caminho(nomeManifesto))
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(conteúdo, &m); err != nil {
+		return m, fmt.Errorf("%s: %v", r.caminho(nomeManifesto), err)
+	}
+	if m.Arquivos == nil {
+		m.Arquivos = map[string]entradaManifesto{}
+	}
+	return m, nil
+}
+
+// gravarManifesto grava em um arquivo temporário e renomeia, para que o
+// manifesto nunca fique pela metade
+func (r repositórioDados) gravarManifesto(m manifesto) error {
+	conteúdo, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporário := r.caminho(nomeManifesto + ".tmp")
+	if err := os.WriteFile(temporário, append(conteúdo, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporário, r.caminho(nomeManifesto))
+}
+
+var padrõesVersão = []*regexp.Regexp{
+	regexp.MustCompile(`-(\d+\.\d+\.\d+)\.txt`),
+	regexp.MustCompile(`[Vv]ersion:? (\d+\.\d+(?:\.\d+)?)`),
+}
+
+// detectarVersão procura a versão do Unicode nos comentários iniciais do arquivo
+func detectarVersão(arquivo io.Reader) string {
+	varredor := bufio.NewScanner(arquivo)
+	for i := 0; i < 20 && varredor.Scan(); i++ {
+		linha := varredor.Text()
+		if len(linha) == 0 || linha[0] != '#' {
+			continue
+		}
+		for _, padrão := range padrõesVersão {
+			if m := padrão.FindStringSubmatch(linha); m != nil {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
+
+// somaArquivo calcula o SHA-256 e o tamanho do arquivo
+func somaArquivo(caminho string) (string, int64, error) {
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return "", 0, err
+	}
+	defer arquivo.Close()
+	h := sha256.New()
+	tamanho, err := io.Copy(h, arquivo)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), tamanho, nil
+}
+
+// registrar acrescenta ao manifesto a soma, o tamanho e a versão do arquivo,
+// e os validadores HTTP usados nas próximas atualizações
+func (r repositórioDados) registrar(nome, origem string, v validadores) error {
+	soma, tamanho, err := somaArquivo(r.caminho(nome))
+	if err != nil {
+		return err
+	}
+	versão := ""
+	if nome == nomeZipUCD {
+		// o UnicodeData.txt não tem cabeçalho; o Blocks.txt informa a versão
+		if arquivo, err := abrirDoZip(r.caminho(nome), "Blocks.txt"); err == nil {
+			versão = detectarVersão(arquivo)
+			arquivo.Close()
+		}
+	} else if arquivo, err := os.Open(r.caminho(nome)); err == nil {
+		versão = detectarVersão(arquivo)
+		arquivo.Close()
+	}
+	m, err := r.lerManifesto()
+	if err != nil {
+		return err
+	}
+	m.Arquivos[nome] = entradaManifesto{
+		Versão: versão, SHA256: soma, Tamanho: tamanho, Origem: origem, Data: time.Now().UTC(),
+		ETag: v.ETag, ÚltimaModificação: v.ÚltimaModificação,
+	}
+	return r.gravarManifesto(m)
+}
+
+// verificar compara o arquivo com a soma registrada no manifesto
+func (r repositórioDados) verificar(nome string) error {
+	m, err := r.lerManifesto()
+	if err != nil {
+		return err
+	}
+	entrada, ok := m.Arquivos[nome]
+	if !ok {
+		return fmt.Errorf("%s: não registrado no manifesto", nome)
+	}
+	soma, _, err := somaArquivo(r.caminho(nome))
+	if err != nil {
+		return err
+	}
+	if soma != entrada.SHA256 {
+		return fmt.Errorf("%s: SHA-256 %s difere do manifesto (%s)", nome, soma, entrada.SHA256)
+	}
+	return nil
+}
+
+// migrar move para o diretório de dados um arquivo que versões antigas do
+// sinais guardavam diretamente no diretório home
+func (r repositórioDados) migrar(nome string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	antigo := filepath.Join(home, nome)
+	if antigo == r.caminho(nome) {
+		return nil
+	}
+	if _, err := os.Stat(antigo); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(r.diretório, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(antigo, r.caminho(nome)); err != nil {
+		if err := copiarArquivo(antigo, r.caminho(nome)); err != nil {
+			return err
+		}
+		os.Remove(antigo)
+	}
+	slog.Info("arquivo de dados movido", "de", antigo, "para", r.caminho(nome))
+	return r.registrar(nome, antigo, validadores{})
+}
+
+func copiarArquivo(origem, destino string) error {
+	entrada, err := os.Open(origem)
+	if err != nil {
+		return err
+	}
+	defer entrada.Close()
+	saída, err := os.Create(destino)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(saída, entrada); err != nil {
+		saída.Close()
+		return err
+	}
+	return saída.Close()
+}
+
+// baixar obtém o arquivo da Web e registra no manifesto
+func (r repositórioDados) baixar(arquivo arquivoUCD) error {
+	if err := os.MkdirAll(r.diretório, 0o755); err != nil {
+		return err
+	}
+	return r.baixarCom(arquivo, r.download)
+}
+
+func (r repositórioDados) baixarCom(arquivo arquivoUCD, opções opçõesDownload) error {
+	opções.obtidos = &validadores{}
+	origem, err := baixarDeEspelhos(r.relativo(arquivo), r.caminho(arquivo.nome), opções)
+	if err != nil {
+		return err
+	}
+	return r.registrar(arquivo.nome, origem, *opções.obtidos)
+}
+
+// atualizar baixa o arquivo de novo só se ele mudou, enviando os
+// validadores do manifesto em uma requisição condicional, e informa se o
+// conteúdo mudou
+func (r repositórioDados) atualizar(arquivo arquivoUCD) (bool, error) {
+	m, err := r.lerManifesto()
+	if err != nil {
+		return false, err
+	}
+	anterior := m.Arquivos[arquivo.nome]
+	opções := r.download
+	opções.Condicional = validadores{ETag: anterior.ETag, ÚltimaModificação: anterior.ÚltimaModificação}
+	err = r.baixarCom(arquivo, opções)
+	if errors.Is(err, ErrNãoModificado) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	m, err = r.lerManifesto()
+	if err != nil {
+		return false, err
+	}
+	return m.Arquivos[arquivo.nome].SHA256 != anterior.SHA256, nil
+}
+
+// instalar baixa o UCD.zip da versão, que guarda todos os arquivos dela;
+// sem versão, baixa o da versão mais recente
+func (r repositórioDados) instalar() error {
+	if _, err := os.Stat(r.caminho(nomeZipUCD)); err == nil {
+		return nil
+	}
+	return r.baixar(arquivoZipUCD)
+}
+
+// abrirLocal devolve o arquivo do diretório de dados ou de dentro do
+// UCD.zip, sem migrar nem baixar nada
+func (r repositórioDados) abrirLocal(nome string) (io.ReadCloser, error) {
+	arquivo, err := os.Open(r.caminho(nome))
+	if !os.IsNotExist(err) {
+		return arquivo, err
+	}
+	caminhoNoZip := nome
+	if descrição, ok := acharArquivoUCD(nome); ok {
+		caminhoNoZip = descrição.remoto
+	}
+	if conteúdo, errZip := abrirDoZip(r.caminho(nomeZipUCD), caminhoNoZip); !errors.Is(errZip, fs.ErrNotExist) {
+		return conteúdo, errZip
+	}
+	return nil, err
+}
+
+// abrir devolve o arquivo do diretório de dados ou do UCD.zip. Se ele
+// ainda não estiver presente, os arquivos sem versão são antes procurados
+// no diretório home; não havendo, é baixado o UCD.zip da versão, ou o da
+// versão mais recente para o repositório sem versão.
+func (r repositórioDados) abrir(nome string) (io.ReadCloser, error) {
+	arquivo, err := r.abrirLocal(nome)
+	if !os.IsNotExist(err) {
+		return arquivo, err
+	}
+	if r.versão == "" {
+		if err := r.migrar(nome); err != nil {
+			return nil, err
+		}
+		if arquivo, err = r.abrirLocal(nome); !os.IsNotExist(err) {
+			return arquivo, err
+		}
+	}
+	if err := r.instalar(); err != nil {
+		return nil, err
+	}
+	return r.abrirLocal(nome)
+}
+
+// Exibir lista os arquivos conhecidos, com versão e situação no manifesto
+func (r repositórioDados) Exibir(w io.Writer, verificar bool) error {
+	m, err := r.lerManifesto()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "diretório: %s\n", r.diretório)
+	nomes := []string{}
+	for _, arquivo := range arquivosUCD {
+		nomes = append(nomes, arquivo.nome)
+	}
+	for nome := range m.Arquivos {
+		if _, ok := acharArquivoUCD(nome); !ok {
+			nomes = append(nomes, nome)
+		}
+	}
+	sort.Strings(nomes[len(arquivosUCD):])
+	for _, nome := range nomes {
+		entrada, registrado := m.Arquivos[nome]
+		_, errStat := os.Stat(r.caminho(nome))
+		situação := "ausente"
+		switch {
+		case errStat != nil && nome != nomeZipUCD:
+			if conteúdo, err := r.abrirLocal(nome); err == nil {
+				conteúdo.Close()
+				situação = "no " + nomeZipUCD
+			}
+		case errStat == nil && !registrado:
+			situação = "não registrado"
+		case errStat == nil && verificar:
+			situação = "ok"
+			if err := r.verificar(nome); err != nil {
+				situação = "corrompido"
+			}
+		case errStat == nil:
+			situação = "presente"
+		}
+		versão := entrada.Versão
+		if versão == "" {
+			versão = "-"
+		}
+		linha := fmt.Sprintf("  %-16s %-8s %-14s %s", nome, versão, situação, abreviarSoma(entrada.SHA256))
+		fmt.Fprintln(w, strings.TrimRight(linha, " "))
+	}
+	return nil
+}
+
+func abreviarSoma(soma string) string {
+	if len(soma) > 12 {
+		return soma[:12]
+	}
+	return soma
+}
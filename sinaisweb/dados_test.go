@@ -0,0 +1,202 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectarVersão(t *testing.T) {
+	casos := []struct {
+		texto    string
+		esperado string
+	}{
+		{"# Blocks-15.1.0.txt\n# Date: 2023-07-28\n", "15.1.0"},
+		{"# emoji-data.txt\n# Used with Unicode Version 15.1\n", "15.1"},
+		{linhas3Da43, ""},
+	}
+	for _, caso := range casos {
+		if obtido := detectarVersão(strings.NewReader(caso.texto)); obtido != caso.esperado {
+			t.Errorf("detectarVersão(%q)\nesperado: %q; recebido: %q", caso.texto, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestRepositórioRegistrarEVerificar(t *testing.T) {
+	r := repositórioDados{diretório: t.TempDir()}
+	os.WriteFile(r.caminho("Blocks.txt"), []byte("# Blocks-9.0.0.txt\n0000..007F; Basic Latin\n"), 0o644)
+	if err := r.registrar("Blocks.txt", "teste", validadores{}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := r.lerManifesto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entrada := m.Arquivos["Blocks.txt"]; entrada.Versão != "9.0.0" || entrada.Tamanho != 43 || len(entrada.SHA256) != 64 {
+		t.Errorf("manifesto: %#v", entrada)
+	}
+	if err := r.verificar("Blocks.txt"); err != nil {
+		t.Errorf("verificar: %v", err)
+	}
+	os.WriteFile(r.caminho("Blocks.txt"), []byte("alterado"), 0o644)
+	if err := r.verificar("Blocks.txt"); err == nil {
+		t.Errorf("verificar deveria detectar arquivo alterado")
+	}
+}
+
+func TestRepositórioMigrarDoHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	antigo := filepath.Join(home, "UnicodeData.txt")
+	os.WriteFile(antigo, []byte(linhas3Da43), 0o644)
+	r := repositórioDados{diretório: filepath.Join(home, ".local", "share", "sinais")}
+	ucd, err := r.abrir("UnicodeData.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ucd.Close()
+	if linhas := carregar(ucd); len(linhas) != 7 {
+		t.Errorf("arquivo migrado tem %d linhas; esperado: 7", len(linhas))
+	}
+	if _, err := os.Stat(antigo); !os.IsNotExist(err) {
+		t.Errorf("%s deveria ter sido removido", antigo)
+	}
+	if err := r.verificar("UnicodeData.txt"); err != nil {
+		t.Errorf("arquivo migrado deveria estar no manifesto: %v", err)
+	}
+}
+
+func TestDiretórioDadosPadrão(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/tmp/dados")
+	if obtido := diretórioDadosPadrão(); obtido != "/tmp/dados/sinais" {
+		t.Errorf("diretórioDadosPadrão()\nesperado: %q; recebido: %q", "/tmp/dados/sinais", obtido)
+	}
+}
+
+func TestCompararVersões(t *testing.T) {
+	casos := []struct {
+		a, b  string
+		sinal int
+	}{
+		{"15.1.0", "15.1.0", 0},
+		{"15.1.0", "15.0.0", 1},
+		{"9.0.0", "10.0.0", -1},
+		{"15.1", "15.1.0", -1},
+	}
+	for _, caso := range casos {
+		obtido := compararVersões(caso.a, caso.b)
+		if (obtido > 0) != (caso.sinal > 0) || (obtido < 0) != (caso.sinal < 0) {
+			t.Errorf("compararVersões(%q, %q) = %d; sinal esperado: %d", caso.a, caso.b, obtido, caso.sinal)
+		}
+	}
+}
+
+func TestRepositórioVersões(t *testing.T) {
+	raiz := repositórioDados{diretório: t.TempDir()}
+	for _, versão := range []string{"9.0.0", "15.1.0", "10.0.0"} {
+		os.MkdirAll(raiz.daVersão(versão).diretório, 0o755)
+		os.WriteFile(raiz.daVersão(versão).caminho("UnicodeData.txt"), []byte(linhas3Da43), 0o644)
+	}
+	os.Mkdir(raiz.caminho("outro"), 0o755)
+	versões, err := raiz.versões()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if esperado := []string{"15.1.0", "10.0.0", "9.0.0"}; !reflect.DeepEqual(versões, esperado) {
+		t.Errorf("versões\nesperado: %q; recebido: %q", esperado, versões)
+	}
+	if err := raiz.ativar("12.0.0"); err == nil {
+		t.Errorf("ativar deveria recusar versão não instalada")
+	}
+	if err := raiz.ativar("10.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if ativa := raiz.versãoAtiva(); ativa != "10.0.0" {
+		t.Errorf("versãoAtiva\nesperado: %q; recebido: %q", "10.0.0", ativa)
+	}
+}
+
+func TestRepositórioRelativo(t *testing.T) {
+	unicodeData, _ := acharArquivoUCD("UnicodeData.txt")
+	emoji, _ := acharArquivoUCD("emoji-data.txt")
+	raiz := repositórioDados{diretório: "/dados"}
+	casos := []struct {
+		r        repositórioDados
+		arquivo  arquivoUCD
+		esperado string
+	}{
+		{raiz, unicodeData, relativoUCD},
+		{raiz, emoji, "UCD/latest/ucd/emoji/emoji-data.txt"},
+		{raiz.daVersão("15.1.0"), unicodeData, "15.1.0/ucd/UnicodeData.txt"},
+		{raiz.daVersão("15.1.0"), emoji, "15.1.0/ucd/emoji/emoji-data.txt"},
+	}
+	for _, caso := range casos {
+		if obtido := caso.r.relativo(caso.arquivo); obtido != caso.esperado {
+			t.Errorf("relativo(%s) na versão %q\nesperado: %q; recebido: %q", caso.arquivo.nome, caso.r.versão, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestConfiguraçãoRepositório_versão(t *testing.T) {
+	cfg := configuraçãoPadrão()
+	cfg.Dados = t.TempDir()
+	raiz := cfg.repositórioRaiz()
+	if r := cfg.repositório(); r.versão != "" {
+		t.Errorf("sem versão ativa, esperado o diretório raiz; recebido: versão %q", r.versão)
+	}
+	os.MkdirAll(raiz.daVersão("14.0.0").diretório, 0o755)
+	os.WriteFile(raiz.daVersão("14.0.0").caminho("UnicodeData.txt"), []byte(linhas3Da43), 0o644)
+	raiz.ativar("14.0.0")
+	if r := cfg.repositório(); r.versão != "14.0.0" {
+		t.Errorf("versão ativa\nesperado: %q; recebido: %q", "14.0.0", r.versão)
+	}
+	cfg.definir("versao", "15.1.0", origemOpção)
+	if r := cfg.repositório(); r.diretório != filepath.Join(cfg.Dados, "15.1.0") {
+		t.Errorf("a opção -versao deveria ter precedência sobre a versão ativa: %q", r.diretório)
+	}
+}
+
+func TestRepositórioAtualizar_condicional(t *testing.T) {
+	conteúdo, etag := linhas3Da43, `"v1"`
+	respostas := []int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gravador := httptest.NewRecorder()
+		gravador.Header().Set("ETag", etag)
+		http.ServeContent(gravador, r, "UnicodeData.txt", time.Time{}, strings.NewReader(conteúdo))
+		respostas = append(respostas, gravador.Code)
+		for chave, valores := range gravador.Header() {
+			w.Header()[chave] = valores
+		}
+		w.WriteHeader(gravador.Code)
+		w.Write(gravador.Body.Bytes())
+	}))
+	defer srv.Close()
+	r := repositórioDados{diretório: t.TempDir(),
+		download: opçõesDownload{Espelhos: []string{srv.URL}, Progresso: progressoSilencioso}}
+	unicodeData, _ := acharArquivoUCD("UnicodeData.txt")
+	if err := r.baixar(unicodeData); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := r.lerManifesto()
+	if m.Arquivos["UnicodeData.txt"].ETag != etag {
+		t.Errorf("ETag no manifesto\nesperado: %q; recebido: %q", etag, m.Arquivos["UnicodeData.txt"].ETag)
+	}
+	if mudou, err := r.atualizar(unicodeData); err != nil || mudou {
+		t.Errorf("arquivo igual: atualizar -> %v, %v; esperado: false, nil", mudou, err)
+	}
+	conteúdo, etag = linhas3Da43+"0044;LATIN CAPITAL LETTER D;Lu;0;L;;;;;N;;;;0064;\n", `"v2"`
+	if mudou, err := r.atualizar(unicodeData); err != nil || !mudou {
+		t.Errorf("arquivo alterado: atualizar -> %v, %v; esperado: true, nil", mudou, err)
+	}
+	if esperado := []int{200, 304, 200}; !reflect.DeepEqual(respostas, esperado) {
+		t.Errorf("respostas do servidor\nesperado: %v; recebido: %v", esperado, respostas)
+	}
+	if lido, _ := os.ReadFile(r.caminho("UnicodeData.txt")); string(lido) != conteúdo {
+		t.Errorf("o arquivo deveria ter sido substituído pela nova versão")
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// alias é um nome alternativo do NameAliases.txt, como uma correção ou
+// uma abreviatura
+type alias struct {
+	Nome string `json:"nome"`
+	Tipo string `json:"tipo"`
+}
+
+// carregarAliases lê as linhas "código;alias;tipo" do NameAliases.txt
+func carregarAliases(arquivo io.Reader) map[rune][]alias {
+	aliases := map[rune][]alias{}
+	varredor := bufio.NewScanner(arquivo)
+	for varredor.Scan() {
+		linha := strings.TrimSpace(varredor.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+		campos := strings.Split(linha, ";")
+		if len(campos) < 3 {
+			continue
+		}
+		código, err := strconv.ParseInt(campos[0], 16, 32)
+		if err != nil {
+			continue
+		}
+		aliases[rune(código)] = append(aliases[rune(código)], alias{Nome: campos[1], Tipo: campos[2]})
+	}
+	return aliases
+}
+
+// propriedadesComparadas lista as propriedades verificadas pelo diff
+var propriedadesComparadas = []struct {
+	nome  string
+	valor func(c Caractere) string
+}{
+	{"nome antigo", func(c Caractere) string { return c.NomeAntigo }},
+	{"categoria", func(c Caractere) string { return c.Categoria }},
+	{"combinação", func(c Caractere) string { return c.Combinação }},
+	{"bidi", func(c Caractere) string { return c.Bidi }},
+	{"decomposição", func(c Caractere) string { return c.Decomposição }},
+	{"valor", func(c Caractere) string { return c.ValorNumérico }},
+	{"espelhado", func(c Caractere) string { return strconv.FormatBool(c.Espelhado) }},
+	{"maiúscula", func(c Caractere) string { return códigoOpcional(c.Maiúscula) }},
+	{"minúscula", func(c Caractere) string { return códigoOpcional(c.Minúscula) }},
+	{"título", func(c Caractere) string { return códigoOpcional(c.Título) }},
+}
+
+// mudança registra o valor de uma propriedade antes e depois
+type mudança struct {
+	Código      string `json:"codigo"`
+	Caractere   string `json:"caractere"`
+	Propriedade string `json:"propriedade"`
+	Antes       string `json:"antes"`
+	Depois      string `json:"depois"`
+}
+
+type aliasNovo struct {
+	Código    string `json:"codigo"`
+	Caractere string `json:"caractere"`
+	alias
+}
+
+// Diferença reúne o que mudou entre duas versões da UCD
+type Diferença struct {
+	De           string      `json:"de"`
+	Para         string      `json:"para"`
+	Adicionados  []registro  `json:"adicionados"`
+	Removidos    []registro  `json:"removidos"`
+	Nomes        []mudança   `json:"nomes"`
+	Propriedades []mudança   `json:"propriedades"`
+	Aliases      []aliasNovo `json:"aliases"`
+}
+
+// faixa é um par de linhas <…, First>/<…, Last> do UnicodeData.txt
+type faixa struct {
+	início Caractere
+	fim    rune
+}
+
+// tabelaUCD indexa as linhas do UnicodeData.txt por código, guardando as
+// faixas como intervalos para não criar uma entrada por runa
+type tabelaUCD struct {
+	caracteres map[rune]Caractere
+	faixas     []faixa
+}
+
+func novaTabelaUCD(linhas []string) tabelaUCD {
+	t := tabelaUCD{caracteres: make(map[rune]Caractere, len(linhas))}
+	for i := 0; i < len(linhas); i++ {
+		if início, fim, ok := faixaEm(linhas, i); ok {
+			t.faixas = append(t.faixas, faixa{início, fim})
+			i++ // a linha Last
+			continue
+		}
+		c := AnalisarCaractere(linhas[i])
+		t.caracteres[c.Runa] = c
+	}
+	return t
+}
+
+// buscar devolve o caractere da runa, com o nome derivado se ela estiver
+// em uma faixa
+func (t tabelaUCD) buscar(runa rune) (Caractere, bool) {
+	if c, ok := t.caracteres[runa]; ok {
+		return c, true
+	}
+	for _, f := range t.faixas {
+		if f.início.Runa <= runa && runa <= f.fim {
+			return caractereNaFaixa(f.início, runa), true
+		}
+	}
+	return Caractere{}, false
+}
+
+// runas lista em ordem todas as runas da tabela, expandindo as faixas
+func (t tabelaUCD) runas() []rune {
+	runas := make([]rune, 0, len(t.caracteres))
+	for runa := range t.caracteres {
+		runas = append(runas, runa)
+	}
+	for _, f := range t.faixas {
+		for runa := f.início.Runa; runa <= f.fim; runa++ {
+			if _, ok := t.caracteres[runa]; !ok {
+				runas = append(runas, runa)
+			}
+		}
+	}
+	sort.Slice(runas, func(i, j int) bool { return runas[i] < runas[j] })
+	return runas
+}
+
+func novaMudança(c Caractere, propriedade, antes, depois string) mudança {
+	return mudança{Código: fmt.Sprintf("U+%04X", c.Runa), Caractere: string(c.Runa),
+		Propriedade: propriedade, Antes: antes, Depois: depois}
+}
+
+// Diferenciar compara as linhas do UnicodeData.txt e os aliases de duas
+// versões; as faixas são comparadas runa a runa
+func Diferenciar(de, para string, antigas, novas []string, aliasesAntigos, aliasesNovos map[rune][]alias) Diferença {
+	d := Diferença{De: de, Para: para, Adicionados: []registro{}, Removidos: []registro{},
+		Nomes: []mudança{}, Propriedades: []mudança{}, Aliases: []aliasNovo{}}
+	anteriores, atuais := novaTabelaUCD(antigas), novaTabelaUCD(novas)
+	for _, runa := range anteriores.runas() {
+		if _, ok := atuais.buscar(runa); !ok {
+			anterior, _ := anteriores.buscar(runa)
+			d.Removidos = append(d.Removidos, novoRegistro(anterior))
+		}
+	}
+	for _, runa := range atuais.runas() {
+		atual, _ := atuais.buscar(runa)
+		anterior, existia := anteriores.buscar(runa)
+		if !existia {
+			d.Adicionados = append(d.Adicionados, novoRegistro(atual))
+		} else {
+			if anterior.Nome != atual.Nome {
+				d.Nomes = append(d.Nomes, novaMudança(atual, "nome", anterior.Nome, atual.Nome))
+			}
+			for _, p := range propriedadesComparadas {
+				if antes, depois := p.valor(anterior), p.valor(atual); antes != depois {
+					d.Propriedades = append(d.Propriedades, novaMudança(atual, p.nome, antes, depois))
+				}
+			}
+		}
+		for _, a := range aliasesNovos[runa] {
+			if !contémAlias(aliasesAntigos[runa], a) {
+				d.Aliases = append(d.Aliases, aliasNovo{fmt.Sprintf("U+%04X", runa), string(runa), a})
+			}
+		}
+	}
+	return d
+}
+
+func contémAlias(aliases []alias, procurado alias) bool {
+	for _, a := range aliases {
+		if a == procurado {
+			return true
+		}
+	}
+	return false
+}
+
+// Exibir escreve a diferença no formato texto ou json
+func (d Diferença) Exibir(w io.Writer, formato string) error {
+	switch formato {
+	case "json":
+		codificador := json.NewEncoder(w)
+		codificador.SetIndent("", "  ")
+		return codificador.Encode(d)
+	case "texto":
+	default:
+		return fmt.Errorf("o diff aceita os formatos texto e json, não %q", formato)
+	}
+	fmt.Fprintf(w, "%s → %s\n", d.De, d.Para)
+	seções := []struct {
+		título string
+		linhas []string
+	}{
+		{"adicionados", linhasDeRegistros(d.Adicionados)},
+		{"removidos", linhasDeRegistros(d.Removidos)},
+		{"nomes alterados", linhasDeMudanças(d.Nomes, false)},
+		{"propriedades alteradas", linhasDeMudanças(d.Propriedades, true)},
+		{"aliases novos", linhasDeAliases(d.Aliases)},
+	}
+	for _, seção := range seções {
+		fmt.Fprintf(w, "\n%s: %d\n", seção.título, len(seção.linhas))
+		for _, linha := range seção.linhas {
+			fmt.Fprintln(w, linha)
+		}
+	}
+	return nil
+}
+
+func linhasDeRegistros(registros []registro) []string {
+	linhas := make([]string, len(registros))
+	for i, r := range registros {
+		linhas[i] = fmt.Sprintf("%s\t%s\t%s", r.Código, r.Caractere, r.Nome)
+	}
+	return linhas
+}
+
+func linhasDeMudanças(mudanças []mudança, comPropriedade bool) []string {
+	linhas := make([]string, len(mudanças))
+	for i, m := range mudanças {
+		prefixo := ""
+		if comPropriedade {
+			prefixo = m.Propriedade + ": "
+		}
+		linhas[i] = fmt.Sprintf("%s\t%s\t%s%s → %s", m.Código, m.Caractere, prefixo, m.Antes, m.Depois)
+	}
+	return linhas
+}
+
+func linhasDeAliases(aliases []aliasNovo) []string {
+	linhas := make([]string, len(aliases))
+	for i, a := range aliases {
+		linhas[i] = fmt.Sprintf("%s\t%s\t%s (%s)", a.Código, a.Caractere, a.Nome, a.Tipo)
+	}
+	return linhas
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var ucdAntiga = []string{
+	"0041;LATIN CAPITAL LETTER A;Lu;0;L;;;;;N;;;;0061;",
+	"01A2;LATIN CAPITAL LETTER OI;Lu;0;L;;;;;N;;;;01A3;",
+	"2118;SCRIPT CAPITAL P;Sm;0;ON;;;;;N;SCRIPT P;;;;",
+	"E000;<Private Use, First>;Co;0;L;;;;;N;;;;;",
+}
+
+var ucdNova = []string{
+	"0041;LATIN CAPITAL LETTER A;Lu;0;L;;;;;N;;;;0061;",
+	"01A2;LATIN CAPITAL LETTER OI;Lu;0;L;;;;;N;LATIN CAPITAL LETTER O I;;;01A3;",
+	"2118;SCRIPT CAPITAL P;So;0;ON;;;;;N;SCRIPT P;;;;",
+	"1F600;GRINNING FACE;So;0;ON;;;;;N;;;;;",
+}
+
+func TestCarregarAliases(t *testing.T) {
+	texto := "# NameAliases-15.1.0.txt\n\n01A2;LATIN CAPITAL LETTER GHA;correction\n0000;NULL;control\n0000;NUL;abbreviation\n"
+	esperado := map[rune][]alias{
+		0x01A2: {{"LATIN CAPITAL LETTER GHA", "correction"}},
+		0x0000: {{"NULL", "control"}, {"NUL", "abbreviation"}},
+	}
+	if obtido := carregarAliases(strings.NewReader(texto)); !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("carregarAliases\nesperado: %v\nrecebido: %v", esperado, obtido)
+	}
+}
+
+func TestDiferenciar(t *testing.T) {
+	aliasesNovos := map[rune][]alias{0x01A2: {{"LATIN CAPITAL LETTER GHA", "correction"}}}
+	d := Diferenciar("9.0.0", "15.1.0", ucdAntiga, ucdNova, nil, aliasesNovos)
+	if len(d.Adicionados) != 1 || d.Adicionados[0].Código != "U+1F600" {
+		t.Errorf("adicionados: %v", d.Adicionados)
+	}
+	if len(d.Removidos) != 1 || d.Removidos[0].Código != "U+E000" {
+		t.Errorf("removidos: %v", d.Removidos)
+	}
+	if len(d.Nomes) != 0 {
+		t.Errorf("nenhum nome deveria mudar: %v", d.Nomes)
+	}
+	esperado := []mudança{
+		{"U+01A2", "Ƣ", "nome antigo", "", "LATIN CAPITAL LETTER O I"},
+		{"U+2118", "℘", "categoria", "Sm", "So"},
+	}
+	if !reflect.DeepEqual(d.Propriedades, esperado) {
+		t.Errorf("propriedades\nesperado: %v\nrecebido: %v", esperado, d.Propriedades)
+	}
+	if len(d.Aliases) != 1 || d.Aliases[0].Nome != "LATIN CAPITAL LETTER GHA" {
+		t.Errorf("aliases: %v", d.Aliases)
+	}
+}
+
+func TestDiferenciar_faixas(t *testing.T) {
+	antiga := []string{
+		"4E00;<CJK Ideograph, First>;Lo;0;L;;;;;N;;;;;",
+		"9FD5;<CJK Ideograph, Last>;Lo;0;L;;;;;N;;;;;",
+	}
+	nova := []string{
+		"4E00;<CJK Ideograph, First>;Lo;0;L;;;;;N;;;;;",
+		"9FEF;<CJK Ideograph, Last>;Lo;0;L;;;;;N;;;;;",
+	}
+	d := Diferenciar("10.0.0", "12.0.0", antiga, nova, nil, nil)
+	if len(d.Adicionados) != 0x9FEF-0x9FD5 {
+		t.Fatalf("adicionados: %d; esperado: %d", len(d.Adicionados), 0x9FEF-0x9FD5)
+	}
+	if primeiro := d.Adicionados[0]; primeiro.Código != "U+9FD6" || primeiro.Nome != "CJK UNIFIED IDEOGRAPH-9FD6" {
+		t.Errorf("primeiro adicionado: %v", primeiro)
+	}
+	if len(d.Removidos) != 0 || len(d.Nomes) != 0 || len(d.Propriedades) != 0 {
+		t.Errorf("só deveria haver adicionados: %v, %v, %v", d.Removidos, d.Nomes, d.Propriedades)
+	}
+}
+
+func TestDiferençaExibir_json(t *testing.T) {
+	var saída bytes.Buffer
+	d := Diferenciar("9.0.0", "15.1.0", ucdAntiga, ucdAntiga, nil, nil)
+	if err := d.Exibir(&saída, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var lido map[string]interface{}
+	if err := json.Unmarshal(saída.Bytes(), &lido); err != nil {
+		t.Fatalf("JSON inválido: %v\n%s", err, saída.String())
+	}
+	if adicionados, ok := lido["adicionados"].([]interface{}); !ok || len(adicionados) != 0 {
+		t.Errorf("adicionados deveria ser uma lista vazia: %v", lido["adicionados"])
+	}
+	if err := d.Exibir(&saída, "caractere"); err == nil {
+		t.Errorf("formato caractere deveria ser recusado")
+	}
+}
+
+func ExampleDiferença_Exibir() {
+	antiga := []string{"0041;LATIN CAPITAL LETTER A;Lu;0;L;;;;;N;;;;0061;"}
+	nova := []string{
+		"0041;LATIN CAPITAL LETTER A;Lu;0;L;;;;;N;;;;0061;",
+		"20BF;BITCOIN SIGN;Sc;0;ET;;;;;N;;;;;",
+	}
+	Diferenciar("9.0.0", "10.0.0", antiga, nova, nil, nil).Exibir(os.Stdout, "texto")
+	// Output:
+	// 9.0.0 → 10.0.0
+	//
+	// adicionados: 1
+	// U+20BF	₿	BITCOIN SIGN
+	//
+	// removidos: 0
+	//
+	// nomes alterados: 0
+	//
+	// propriedades alteradas: 0
+	//
+	// aliases novos: 0
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// opçõesDownload ajusta o comportamento de baixarUCD; valores zero são
+// trocados pelos padrões
+type opçõesDownload struct {
+	SHA256        string        // soma esperada em hexadecimal; vazia para não verificar
+	Tentativas    int           // número máximo de tentativas
+	TempoLimite   time.Duration // limite para conectar, receber cabeçalhos ou ficar sem dados
+	EsperaInicial time.Duration // espera antes da segunda tentativa; dobra a cada falha
+	EsperaMáxima  time.Duration
+	Progresso     string      // modo de exibição do progresso; veja modosProgresso
+	Espelhos      []string    // servidores ou diretórios tentados em ordem
+	Condicional   validadores // enviados para só baixar o arquivo se ele mudou
+	Proxy         string      // URL do proxy; vazia para usar HTTP_PROXY e HTTPS_PROXY
+	CA            string      // arquivo PEM com certificados aceitos além dos do sistema
+	Inseguro      bool        // não verifica o certificado TLS do servidor
+	andamento     *andamento
+	obtidos       *validadores // recebe os validadores da resposta, se não for nil
+}
+
+// validadores identificam a versão de um arquivo remoto, para que uma
+// requisição condicional evite baixá-lo de novo se ele não mudou
+type validadores struct {
+	ETag              string
+	ÚltimaModificação string
+}
+
+const (
+	tentativasPadrão    = 4
+	tempoLimitePadrão   = 30 * time.Second
+	esperaInicialPadrão = time.Second
+	esperaMáximaPadrão  = 30 * time.Second
+)
+
+func (o opçõesDownload) comPadrões() opçõesDownload {
+	if o.Tentativas <= 0 {
+		o.Tentativas = tentativasPadrão
+	}
+	if o.TempoLimite <= 0 {
+		o.TempoLimite = tempoLimitePadrão
+	}
+	if o.EsperaInicial <= 0 {
+		o.EsperaInicial = esperaInicialPadrão
+	}
+	if o.EsperaMáxima <= 0 {
+		o.EsperaMáxima = esperaMáximaPadrão
+	}
+	if len(o.Espelhos) == 0 {
+		o.Espelhos = espelhosPadrão
+	}
+	return o
+}
+
+// ErroStatusHTTP indica que o servidor respondeu com status diferente de 200
+type ErroStatusHTTP struct {
+	URL    string
+	Status int
+}
+
+func (e *ErroStatusHTTP) Error() string {
+	return fmt.Sprintf("%s: status HTTP %d %s", e.URL, e.Status, http.StatusText(e.Status))
+}
+
+// ErroConteúdo indica que o arquivo baixado não parece um arquivo da UCD,
+// como acontece quando um proxy devolve uma página de erro
+type ErroConteúdo struct {
+	URL    string
+	Motivo string
+}
+
+func (e *ErroConteúdo) Error() string {
+	return fmt.Sprintf("%s: conteúdo inválido: %s", e.URL, e.Motivo)
+}
+
+// ErroSomaSHA256 indica que a soma do arquivo baixado difere da esperada
+type ErroSomaSHA256 struct {
+	URL      string
+	Esperada string
+	Obtida   string
+}
+
+func (e *ErroSomaSHA256) Error() string {
+	return fmt.Sprintf("%s: SHA-256 %s, esperado %s", e.URL, e.Obtida, e.Esperada)
+}
+
+// validarConteúdo examina as primeiras linhas do arquivo: UnicodeData.txt
+// tem 15 campos por linha, e os demais arquivos da UCD têm só comentários
+// e linhas com campos separados por ';'
+func validarConteúdo(nome string, conteúdo io.Reader) string {
+	unicodeData := strings.HasSuffix(nome, "UnicodeData.txt")
+	varredor := bufio.NewScanner(conteúdo)
+	linhasDeDados := 0
+	for i := 0; i < 50 && varredor.Scan(); i++ {
+		linha := strings.TrimSpace(varredor.Text())
+		if linha == "" || (!unicodeData && strings.HasPrefix(linha, "#")) {
+			continue
+		}
+		if strings.HasPrefix(linha, "<") {
+			return "parece HTML"
+		}
+		campos := strings.Split(linha, ";")
+		if unicodeData {
+			if len(campos) != 15 {
+				return fmt.Sprintf("linha %d tem %d campos; esperado: 15", i+1, len(campos))
+			}
+			if _, err := strconv.ParseUint(campos[0], 16, 32); err != nil {
+				return fmt.Sprintf("linha %d não começa com um código hexadecimal", i+1)
+			}
+		} else if len(campos) < 2 {
+			return fmt.Sprintf("linha %d não tem campos separados por ';'", i+1)
+		}
+		linhasDeDados++
+	}
+	if linhasDeDados == 0 {
+		return "nenhuma linha de dados"
+	}
+	return ""
+}
+
+// recuperável informa se vale a pena tentar de novo depois do erro
+func recuperável(err error) bool {
+	if errors.Is(err, ErrNãoModificado) {
+		return false
+	}
+	var status *ErroStatusHTTP
+	if errors.As(err, &status) {
+		return status.Status >= 500 || status.Status == http.StatusTooManyRequests ||
+			status.Status == http.StatusRequestTimeout
+	}
+	var conteúdo *ErroConteúdo
+	var soma *ErroSomaSHA256
+	var certificado *tls.CertificateVerificationError
+	return !errors.As(err, &conteúdo) && !errors.As(err, &soma) && !errors.As(err, &certificado)
+}
+
+// espera calcula o intervalo antes da tentativa seguinte à de número n,
+// dobrando a cada tentativa até o máximo configurado
+func (o opçõesDownload) espera(n int) time.Duration {
+	espera := o.EsperaInicial
+	for i := 1; i < n && espera < o.EsperaMáxima; i++ {
+		espera *= 2
+	}
+	if espera > o.EsperaMáxima {
+		espera = o.EsperaMáxima
+	}
+	return espera
+}
+
+// novoClienteHTTP configura proxy, certificados e tempos limite do cliente
+func novoClienteHTTP(opções opçõesDownload) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if opções.Proxy != "" {
+		endereço, err := url.Parse(opções.Proxy)
+		if err != nil || endereço.Host == "" {
+			return nil, fmt.Errorf("proxy inválido: %q", opções.Proxy)
+		}
+		proxy = http.ProxyURL(endereço)
+	}
+	configTLS := &tls.Config{InsecureSkipVerify: opções.Inseguro}
+	if opções.CA != "" {
+		pem, err := os.ReadFile(opções.CA)
+		if err != nil {
+			return nil, err
+		}
+		certificados, err := x509.SystemCertPool()
+		if err != nil {
+			certificados = x509.NewCertPool()
+		}
+		if !certificados.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: nenhum certificado PEM encontrado", opções.CA)
+		}
+		configTLS.RootCAs = certificados
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 proxy,
+			TLSClientConfig:       configTLS,
+			DialContext:           (&net.Dialer{Timeout: opções.TempoLimite}).DialContext,
+			TLSHandshakeTimeout:   opções.TempoLimite,
+			ResponseHeaderTimeout: opções.TempoLimite,
+		},
+	}, nil
+}
+
+// leitorVigiado cancela a requisição se o corpo ficar parado por mais
+// tempo que o limite, para que uma conexão travada não bloqueie para sempre
+type leitorVigiado struct {
+	leitor   io.Reader
+	limite   time.Duration
+	alarme   *time.Timer
+	esgotado atomic.Bool
+}
+
+func vigiar(leitor io.Reader, limite time.Duration, cancelar func()) *leitorVigiado {
+	l := &leitorVigiado{leitor: leitor, limite: limite}
+	l.alarme = time.AfterFunc(limite, func() {
+		l.esgotado.Store(true)
+		cancelar()
+	})
+	return l
+}
+
+func (l *leitorVigiado) Read(p []byte) (int, error) {
+	n, err := l.leitor.Read(p)
+	l.alarme.Reset(l.limite)
+	if err != nil && l.esgotado.Load() {
+		return n, &ErroTempoEsgotado{Limite: l.limite}
+	}
+	return n, err
+}
+
+// ErrNãoModificado indica que o servidor respondeu 304 a uma requisição
+// condicional: o arquivo local já é o mais recente
+var ErrNãoModificado = errors.New("arquivo não modificado")
+
+// ErrDownloadCancelado indica que o download foi interrompido pelo usuário
+var ErrDownloadCancelado = errors.New("download cancelado")
+
+// ErroTempoEsgotado indica que a conexão ficou parada além do limite
+type ErroTempoEsgotado struct {
+	Limite time.Duration
+}
+
+func (e *ErroTempoEsgotado) Error() string {
+	return fmt.Sprintf("download parado há mais de %v", e.Limite)
+}
+
+// baixarParte faz uma tentativa de download, continuando de onde o arquivo
+// parcial parou se o servidor aceitar requisições com Range e o arquivo
+// no servidor ainda for o mesmo, segundo o If-Range
+func baixarParte(ctx context.Context, cliente *http.Client, url, parcial string, opções opçõesDownload) error {
+	arquivo, err := os.OpenFile(parcial, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer arquivo.Close()
+	info, err := arquivo.Stat()
+	if err != nil {
+		return err
+	}
+	início := info.Size()
+	validador := lerValidador(parcial)
+	if início > 0 && validador == "" {
+		// sem validador não há como saber se o arquivo no servidor mudou
+		if err := arquivo.Truncate(0); err != nil {
+			return err
+		}
+		início = 0
+	}
+
+	ctx, cancelar := context.WithCancel(ctx)
+	defer cancelar()
+	requisição, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if início > 0 {
+		requisição.Header.Set("Range", fmt.Sprintf("bytes=%d-", início))
+		requisição.Header.Set("If-Range", validador)
+	} else {
+		if opções.Condicional.ETag != "" {
+			requisição.Header.Set("If-None-Match", opções.Condicional.ETag)
+		}
+		if opções.Condicional.ÚltimaModificação != "" {
+			requisição.Header.Set("If-Modified-Since", opções.Condicional.ÚltimaModificação)
+		}
+	}
+	resposta, err := cliente.Do(requisição)
+	if err != nil {
+		return err
+	}
+	defer resposta.Body.Close()
+
+	switch resposta.StatusCode {
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(resposta.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", início)) {
+			arquivo.Truncate(0)
+			return fmt.Errorf("%s: Content-Range inesperado: %q", url, resposta.Header.Get("Content-Range"))
+		}
+		if _, err := arquivo.Seek(início, io.SeekStart); err != nil {
+			return err
+		}
+	case http.StatusOK:
+		if err := arquivo.Truncate(0); err != nil {
+			return err
+		}
+		if err := salvarValidador(parcial, resposta.Header); err != nil {
+			return err
+		}
+	case http.StatusNotModified:
+		return ErrNãoModificado
+	case http.StatusRequestedRangeNotSatisfiable:
+		arquivo.Truncate(0)
+		return fmt.Errorf("%s: servidor recusou continuar a partir do byte %d", url, início)
+	default:
+		return &ErroStatusHTTP{URL: url, Status: resposta.StatusCode}
+	}
+	if opções.obtidos != nil {
+		opções.obtidos.ETag = resposta.Header.Get("ETag")
+		opções.obtidos.ÚltimaModificação = resposta.Header.Get("Last-Modified")
+	}
+	var destino io.Writer = arquivo
+	if a := opções.andamento; a != nil {
+		if resposta.StatusCode == http.StatusOK {
+			início = 0
+		}
+		total := int64(-1)
+		if resposta.ContentLength >= 0 {
+			total = início + resposta.ContentLength
+		}
+		a.iniciar(início, total)
+		destino = io.MultiWriter(arquivo, a)
+	}
+	corpo := vigiar(resposta.Body, opções.TempoLimite, cancelar)
+	defer corpo.alarme.Stop()
+	_, err = io.Copy(destino, corpo)
+	return err
+}
+
+// caminhoValidador é onde fica o validador da resposta que começou o
+// arquivo parcial
+func caminhoValidador(parcial string) string {
+	return parcial + ".validador"
+}
+
+// salvarValidador guarda a ETag forte ou, na falta dela, o Last-Modified
+// da resposta, que o If-Range usa para retomar só o mesmo arquivo
+func salvarValidador(parcial string, cabeçalho http.Header) error {
+	validador := cabeçalho.Get("ETag")
+	if validador == "" || strings.HasPrefix(validador, "W/") {
+		validador = cabeçalho.Get("Last-Modified")
+	}
+	if validador == "" {
+		if err := os.Remove(caminhoValidador(parcial)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(caminhoValidador(parcial), []byte(validador), 0o644)
+}
+
+func lerValidador(parcial string) string {
+	conteúdo, err := os.ReadFile(caminhoValidador(parcial))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(conteúdo))
+}
+
+// descartarParcial remove o arquivo parcial e o seu validador
+func descartarParcial(parcial string) {
+	os.Remove(parcial)
+	os.Remove(caminhoValidador(parcial))
+}
+
+// validarArquivo confere o conteúdo e, se pedido, a soma SHA-256 do arquivo
+func validarArquivo(url, nome, caminho string, opções opçõesDownload) error {
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return err
+	}
+	defer arquivo.Close()
+	motivo := ""
+	if strings.HasSuffix(nome, ".zip") {
+		motivo = validarZip(caminho)
+	} else {
+		motivo = validarConteúdo(nome, arquivo)
+	}
+	if motivo != "" {
+		return &ErroConteúdo{URL: url, Motivo: motivo}
+	}
+	if opções.SHA256 == "" {
+		return nil
+	}
+	obtida, _, err := somaArquivo(caminho)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(opções.SHA256, obtida) {
+		return &ErroSomaSHA256{URL: url, Esperada: opções.SHA256, Obtida: obtida}
+	}
+	return nil
+}
+
+// baixarAtomicamente faz o trabalho de baixarUCD, tentando de novo com
+// espera exponencial após falhas recuperáveis. O download é feito em um
+// arquivo ".parcial", retomado na tentativa seguinte; um download com
+// falha nunca deixa arquivo no caminho final, e o cancelamento do contexto
+// remove também o arquivo parcial.
+func baixarAtomicamente(ctx context.Context, url, caminho string, opções opçõesDownload) error {
+	opções = opções.comPadrões()
+	parcial := caminho + ".parcial"
+	cliente, err := novoClienteHTTP(opções)
+	if err != nil {
+		return err
+	}
+	for tentativa := 1; tentativa <= opções.Tentativas; tentativa++ {
+		if tentativa > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opções.espera(tentativa - 1)):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err = baixarParte(ctx, cliente, url, parcial, opções); err == nil || !recuperável(err) {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		descartarParcial(parcial)
+		return fmt.Errorf("%s: %w", url, ErrDownloadCancelado)
+	}
+	if err == nil {
+		err = validarArquivo(url, filepath.Base(caminho), parcial, opções)
+	}
+	if err != nil {
+		if info, errStat := os.Stat(parcial); !recuperável(err) || (errStat == nil && info.Size() == 0) {
+			descartarParcial(parcial)
+		}
+		return err
+	}
+	os.Remove(caminhoValidador(parcial))
+	return os.Rename(parcial, caminho)
+}
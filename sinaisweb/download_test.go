@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func servidorDeTeste(status int, corpo string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(corpo))
+		}))
+}
+
+func TestBaixarAtomicamente_erros(t *testing.T) {
+	casos := []struct {
+		descrição string
+		status    int
+		corpo     string
+		nome      string
+		soma      string
+		erro      interface{}
+	}{
+		{"404", http.StatusNotFound, "not found", "UnicodeData.txt", "", new(*ErroStatusHTTP)},
+		{"página de proxy", http.StatusOK, "<html><body>Acesso negado</body></html>", "UnicodeData.txt", "", new(*ErroConteúdo)},
+		{"campos faltando", http.StatusOK, "0041;LATIN CAPITAL LETTER A;Lu\n", "UnicodeData.txt", "", new(*ErroConteúdo)},
+		{"vazio", http.StatusOK, "", "Blocks.txt", "", new(*ErroConteúdo)},
+		{"soma errada", http.StatusOK, linhas3Da43, "UnicodeData.txt", strings.Repeat("0", 64), new(*ErroSomaSHA256)},
+	}
+	for _, caso := range casos {
+		srv := servidorDeTeste(caso.status, caso.corpo)
+		diretório := t.TempDir()
+		caminho := filepath.Join(diretório, caso.nome)
+		err := baixarAtomicamente(context.Background(), srv.URL, caminho, opçõesDownload{SHA256: caso.soma})
+		srv.Close()
+		if err == nil || !errors.As(err, caso.erro) {
+			t.Errorf("%s: erro esperado do tipo %T; recebido: %v", caso.descrição, caso.erro, err)
+		}
+		if sobras, _ := os.ReadDir(diretório); len(sobras) != 0 {
+			t.Errorf("%s: download com falha deixou arquivos: %v", caso.descrição, sobras)
+		}
+	}
+}
+
+func TestBaixarAtomicamente_somaCorreta(t *testing.T) {
+	srv := servidorDeTeste(http.StatusOK, linhas3Da43)
+	defer srv.Close()
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	soma := fmt.Sprintf("%x", sha256.Sum256([]byte(linhas3Da43)))
+	if err := baixarAtomicamente(context.Background(), srv.URL, caminho, opçõesDownload{SHA256: soma}); err != nil {
+		t.Fatal(err)
+	}
+	conteúdo, _ := os.ReadFile(caminho)
+	if string(conteúdo) != linhas3Da43 {
+		t.Errorf("conteúdo gravado difere do servido: %q", conteúdo)
+	}
+}
+
+func TestValidarConteúdo(t *testing.T) {
+	casos := []struct {
+		nome   string
+		texto  string
+		válido bool
+	}{
+		{"UnicodeData.txt", linhas3Da43, true},
+		{"Blocks.txt", "# Blocks-15.1.0.txt\n\n0000..007F; Basic Latin\n", true},
+		{"Blocks.txt", "# só comentários\n", false},
+		{"Scripts.txt", "<!DOCTYPE html>", false},
+	}
+	for _, caso := range casos {
+		motivo := validarConteúdo(caso.nome, strings.NewReader(caso.texto))
+		if (motivo == "") != caso.válido {
+			t.Errorf("validarConteúdo(%q, %q) -> %q", caso.nome, caso.texto, motivo)
+		}
+	}
+}
+
+// servidorInstável falha de formas diferentes nas primeiras requisições e
+// registra os cabeçalhos Range e If-Range recebidos
+type servidorInstável struct {
+	falhas   []func(w http.ResponseWriter, r *http.Request)
+	ranges   []string
+	ifRanges []string
+}
+
+func (s *servidorInstável) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.ifRanges = append(s.ifRanges, r.Header.Get("If-Range"))
+	w.Header().Set("ETag", `"v1"`)
+	if n := len(s.ranges); n <= len(s.falhas) {
+		s.falhas[n-1](w, r)
+		return
+	}
+	http.ServeContent(w, r, "UnicodeData.txt", time.Time{}, strings.NewReader(linhas3Da43))
+}
+
+func responderMetade(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Length", fmt.Sprint(len(linhas3Da43)))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(linhas3Da43[:len(linhas3Da43)/2]))
+}
+
+func opçõesDeTeste() opçõesDownload {
+	return opçõesDownload{Tentativas: 4, TempoLimite: 200 * time.Millisecond,
+		EsperaInicial: time.Millisecond, EsperaMáxima: 5 * time.Millisecond}
+}
+
+func TestBaixarAtomicamente_retomaDepoisDeFalhas(t *testing.T) {
+	instável := &servidorInstável{falhas: []func(http.ResponseWriter, *http.Request){
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+		responderMetade,
+	}}
+	srv := httptest.NewServer(instável)
+	defer srv.Close()
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	if err := baixarAtomicamente(context.Background(), srv.URL, caminho, opçõesDeTeste()); err != nil {
+		t.Fatal(err)
+	}
+	if conteúdo, _ := os.ReadFile(caminho); string(conteúdo) != linhas3Da43 {
+		t.Errorf("conteúdo retomado difere do original: %q", conteúdo)
+	}
+	esperado := []string{"", "", fmt.Sprintf("bytes=%d-", len(linhas3Da43)/2)}
+	if !reflect.DeepEqual(instável.ranges, esperado) {
+		t.Errorf("cabeçalhos Range\nesperado: %q; recebido: %q", esperado, instável.ranges)
+	}
+	if obtido := instável.ifRanges[2]; obtido != `"v1"` {
+		t.Errorf("If-Range\nesperado: %q; recebido: %q", `"v1"`, obtido)
+	}
+	if _, err := os.Stat(caminhoValidador(caminho + ".parcial")); !os.IsNotExist(err) {
+		t.Errorf("o validador deveria ser removido com o parcial")
+	}
+}
+
+func TestBaixarAtomicamente_parcialDeOutroArquivo(t *testing.T) {
+	casos := []struct {
+		nome      string
+		validador string
+	}{
+		{"sem validador", ""},
+		{"validador antigo", `"v0"`},
+	}
+	for _, caso := range casos {
+		instável := &servidorInstável{}
+		srv := httptest.NewServer(instável)
+		caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+		parcial := caminho + ".parcial"
+		os.WriteFile(parcial, []byte("0000;VERSAO ANTIGA;Cc;0;BN;;;;;N;;;;;\n"), 0o644)
+		if caso.validador != "" {
+			os.WriteFile(caminhoValidador(parcial), []byte(caso.validador), 0o644)
+		}
+		if err := baixarAtomicamente(context.Background(), srv.URL, caminho, opçõesDeTeste()); err != nil {
+			t.Fatalf("%s: %v", caso.nome, err)
+		}
+		srv.Close()
+		if conteúdo, _ := os.ReadFile(caminho); string(conteúdo) != linhas3Da43 {
+			t.Errorf("%s: o parcial não deveria ser aproveitado: %q", caso.nome, conteúdo)
+		}
+	}
+}
+
+func TestBaixarAtomicamente_conexãoParada(t *testing.T) {
+	instável := &servidorInstável{falhas: []func(http.ResponseWriter, *http.Request){
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", fmt.Sprint(len(linhas3Da43)))
+			w.Write([]byte(linhas3Da43[:10]))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		},
+	}}
+	srv := httptest.NewServer(instável)
+	defer srv.Close()
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	if err := baixarAtomicamente(context.Background(), srv.URL, caminho, opçõesDeTeste()); err != nil {
+		t.Fatal(err)
+	}
+	if len(instável.ranges) != 2 || instável.ranges[1] != "bytes=10-" {
+		t.Errorf("deveria retomar do byte 10 após o tempo limite: %q", instável.ranges)
+	}
+}
+
+func TestBaixarAtomicamente_desisteDepoisDasTentativas(t *testing.T) {
+	requisições := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requisições++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	err := baixarAtomicamente(context.Background(), srv.URL, filepath.Join(t.TempDir(), "UnicodeData.txt"), opçõesDeTeste())
+	var status *ErroStatusHTTP
+	if !errors.As(err, &status) || status.Status != http.StatusBadGateway {
+		t.Errorf("erro esperado: status 502; recebido: %v", err)
+	}
+	if requisições != 4 {
+		t.Errorf("requisições esperadas: 4; recebidas: %d", requisições)
+	}
+}
+
+func TestEspera(t *testing.T) {
+	opções := opçõesDownload{EsperaInicial: time.Second, EsperaMáxima: 5 * time.Second}
+	for n, esperado := range []time.Duration{0, 1, 2, 4, 5, 5} {
+		if n == 0 {
+			continue
+		}
+		if obtido := opções.espera(n); obtido != esperado*time.Second {
+			t.Errorf("espera(%d)\nesperado: %v; recebido: %v", n, esperado*time.Second, obtido)
+		}
+	}
+}
+
+func TestBaixarAtomicamente_tls(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(linhas3Da43))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // o cliente sem a CA recusa o certificado
+	srv.StartTLS()
+	defer srv.Close()
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644)
+	vazio := filepath.Join(dir, "vazio.pem")
+	os.WriteFile(vazio, []byte("não é PEM"), 0o644)
+	casos := []struct {
+		descrição string
+		opções    opçõesDownload
+		válido    bool
+	}{
+		{"sem a CA do servidor", opçõesDownload{}, false},
+		{"com a CA do servidor", opçõesDownload{CA: ca}, true},
+		{"inseguro", opçõesDownload{Inseguro: true}, true},
+		{"arquivo sem certificados", opçõesDownload{CA: vazio}, false},
+	}
+	for i, caso := range casos {
+		caminho := filepath.Join(dir, fmt.Sprintf("UnicodeData-%d.txt", i))
+		err := baixarAtomicamente(context.Background(), srv.URL, caminho, caso.opções)
+		if (err == nil) != caso.válido {
+			t.Errorf("%s: erro %v", caso.descrição, err)
+		}
+	}
+}
+
+func TestBaixarAtomicamente_proxy(t *testing.T) {
+	pedidos := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pedidos = append(pedidos, r.URL.String())
+		w.Write([]byte(linhas3Da43))
+	}))
+	defer proxy.Close()
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	destino := "http://ucd.exemplo/Public/UCD/latest/ucd/UnicodeData.txt"
+	if err := baixarAtomicamente(context.Background(), destino, caminho, opçõesDownload{Proxy: proxy.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pedidos, []string{destino}) {
+		t.Errorf("pedidos recebidos pelo proxy\nesperado: %q; recebido: %q", []string{destino}, pedidos)
+	}
+	if err := baixarAtomicamente(context.Background(), destino, caminho, opçõesDownload{Proxy: "não é url"}); err == nil {
+		t.Errorf("proxy inválido deveria dar erro")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInterrompido indica que o usuário teclou Ctrl-C durante a edição
+var ErrInterrompido = errors.New("interrompido")
+
+const (
+	teclaCtrlA     = 1
+	teclaCtrlB     = 2
+	teclaCtrlC     = 3
+	teclaCtrlD     = 4
+	teclaCtrlE     = 5
+	teclaCtrlF     = 6
+	teclaCtrlH     = 8
+	teclaCtrlK     = 11
+	teclaCtrlN     = 14
+	teclaCtrlP     = 16
+	teclaCtrlU     = 21
+	teclaCtrlW     = 23
+	teclaEnter     = 13
+	teclaNovaLinha = 10
+	teclaEsc       = 27
+	teclaApagar    = 127
+)
+
+// editorLinha lê linhas de um terminal em modo bruto, com edição e
+// navegação pelo histórico
+type editorLinha struct {
+	entrada   *bufio.Reader
+	saída     io.Writer
+	histórico []string
+}
+
+func novoEditorLinha(entrada io.Reader, saída io.Writer, histórico []string) *editorLinha {
+	return &editorLinha{entrada: bufio.NewReader(entrada), saída: saída, histórico: histórico}
+}
+
+// adicionarAoHistórico guarda a linha, sem repetir a última
+func (e *editorLinha) adicionarAoHistórico(linha string) {
+	if strings.TrimSpace(linha) == "" {
+		return
+	}
+	if n := len(e.histórico); n > 0 && e.histórico[n-1] == linha {
+		return
+	}
+	e.histórico = append(e.histórico, linha)
+}
+
+func (e *editorLinha) redesenhar(prompt string, buf []rune, pos int) {
+	fmt.Fprintf(e.saída, "\r%s%s\x1b[K", prompt, string(buf))
+	if atrás := len(buf) - pos; atrás > 0 {
+		fmt.Fprintf(e.saída, "\x1b[%dD", atrás)
+	}
+}
+
+// lerSequênciaEsc interpreta o que vem depois de ESC e devolve a tecla
+// equivalente: 'A' (acima), 'B' (abaixo), 'C' (direita), 'D' (esquerda),
+// 'H' (início), 'F' (fim), '3' (delete), '5' (página acima), '6' (página
+// abaixo) ou 0 se não reconhecida. A sequência é lida até o byte final,
+// entre 0x40 e 0x7E, e os modificadores, como em ESC[1;5C (Ctrl+direita),
+// são ignorados.
+func lerSequênciaEsc(entrada *bufio.Reader) rune {
+	r, _, err := entrada.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+	parâmetros := []rune{}
+	for {
+		if r, _, err = entrada.ReadRune(); err != nil {
+			return 0
+		}
+		if r >= 0x40 && r <= 0x7E {
+			break
+		}
+		parâmetros = append(parâmetros, r)
+	}
+	switch r {
+	case 'A', 'B', 'C', 'D', 'H', 'F':
+		return r
+	case '~':
+		número, _, _ := strings.Cut(string(parâmetros), ";")
+		switch número {
+		case "1", "7":
+			return 'H'
+		case "4", "8":
+			return 'F'
+		case "3", "5", "6":
+			return rune(número[0])
+		}
+	}
+	return 0
+}
+
+// LerLinha exibe o prompt e devolve a linha editada pelo usuário.
+// Devolve io.EOF se o usuário tecla Ctrl-D com a linha vazia.
+func (e *editorLinha) LerLinha(prompt string) (string, error) {
+	var buf []rune
+	pos := 0
+	índice := len(e.histórico)
+	rascunho := ""
+	fmt.Fprint(e.saída, prompt)
+	for {
+		r, _, err := e.entrada.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case teclaEnter, teclaNovaLinha:
+			fmt.Fprint(e.saída, "\r\n")
+			return string(buf), nil
+		case teclaCtrlC:
+			fmt.Fprint(e.saída, "^C\r\n")
+			return "", ErrInterrompido
+		case teclaCtrlD:
+			if len(buf) == 0 {
+				fmt.Fprint(e.saída, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case teclaApagar, teclaCtrlH:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case teclaCtrlA:
+			pos = 0
+		case teclaCtrlE:
+			pos = len(buf)
+		case teclaCtrlB:
+			if pos > 0 {
+				pos--
+			}
+		case teclaCtrlF:
+			if pos < len(buf) {
+				pos++
+			}
+		case teclaCtrlK:
+			buf = buf[:pos]
+		case teclaCtrlU:
+			buf = append([]rune{}, buf[pos:]...)
+			pos = 0
+		case teclaCtrlW:
+			início := pos
+			for início > 0 && buf[início-1] == ' ' {
+				início--
+			}
+			for início > 0 && buf[início-1] != ' ' {
+				início--
+			}
+			buf = append(buf[:início], buf[pos:]...)
+			pos = início
+		case teclaCtrlP, teclaCtrlN, teclaEsc:
+			tecla := 'A'
+			if r == teclaCtrlN {
+				tecla = 'B'
+			} else if r == teclaEsc {
+				tecla = lerSequênciaEsc(e.entrada)
+			}
+			switch tecla {
+			case 'A':
+				if índice > 0 {
+					if índice == len(e.histórico) {
+						rascunho = string(buf)
+					}
+					índice--
+					buf = []rune(e.histórico[índice])
+					pos = len(buf)
+				}
+			case 'B':
+				if índice < len(e.histórico) {
+					índice++
+					if índice == len(e.histórico) {
+						buf = []rune(rascunho)
+					} else {
+						buf = []rune(e.histórico[índice])
+					}
+					pos = len(buf)
+				}
+			case 'C':
+				if pos < len(buf) {
+					pos++
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+				}
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(buf)
+			case '3':
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if r < ' ' {
+				continue
+			}
+			buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+			pos++
+		}
+		e.redesenhar(prompt, buf, pos)
+	}
+}
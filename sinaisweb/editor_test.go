@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLerLinha(t *testing.T) {
+	casos := []struct {
+		descrição string
+		teclas    string
+		histórico []string
+		esperado  string
+	}{
+		{"texto simples", "cat\r", nil, "cat"},
+		{"apagar", "catz\x7f\r", nil, "cat"},
+		{"inserir no meio", "ct\x1b[Da\r", nil, "cat"},
+		{"início e fim", "at\x01c\x05s\r", nil, "cats"},
+		{"apagar palavra", "cat face\x17eyes\r", nil, "cat eyes"},
+		{"apagar até o início", "cat face\x15eyes\r", nil, "eyes"},
+		{"histórico acima", "\x1b[A\r", []string{"smiling", "cat"}, "cat"},
+		{"histórico acima duas vezes", "\x1b[A\x1b[A\r", []string{"smiling", "cat"}, "smiling"},
+		{"histórico volta ao rascunho", "sn\x1b[A\x1b[B\r", []string{"cat"}, "sn"},
+		{"delete", "xcat\x01\x1b[3~\r", nil, "cat"},
+		{"ctrl+direita", "ct\x1b[D\x1b[1;5Ca\r", nil, "cta"},
+		{"shift+acima", "\x1b[1;2A\r", []string{"smiling", "cat"}, "cat"},
+		{"sequência desconhecida", "c\x1b[200~at\r", nil, "cat"},
+		{"F5 não é início", "at\x1b[15~\r", nil, "at"},
+		{"delete com modificador", "xcat\x01\x1b[3;5~\r", nil, "cat"},
+	}
+	for _, caso := range casos {
+		editor := novoEditorLinha(strings.NewReader(caso.teclas), io.Discard, caso.histórico)
+		obtido, err := editor.LerLinha("> ")
+		if err != nil || obtido != caso.esperado {
+			t.Errorf("%s: LerLinha(%q)\nesperado: %q; recebido: %q, %v",
+				caso.descrição, caso.teclas, caso.esperado, obtido, err)
+		}
+	}
+}
+
+func TestLerLinha_controles(t *testing.T) {
+	editor := novoEditorLinha(strings.NewReader("\x04"), io.Discard, nil)
+	if _, err := editor.LerLinha("> "); err != io.EOF {
+		t.Errorf("Ctrl-D com linha vazia\nesperado: io.EOF; recebido: %v", err)
+	}
+	editor = novoEditorLinha(strings.NewReader("cat\x03"), io.Discard, nil)
+	if _, err := editor.LerLinha("> "); err != ErrInterrompido {
+		t.Errorf("Ctrl-C\nesperado: ErrInterrompido; recebido: %v", err)
+	}
+}
+
+func TestAdicionarAoHistórico(t *testing.T) {
+	editor := novoEditorLinha(strings.NewReader(""), io.Discard, nil)
+	for _, linha := range []string{"cat", "cat", " ", "dog", "cat"} {
+		editor.adicionarAoHistórico(linha)
+	}
+	esperado := "cat|dog|cat"
+	if obtido := strings.Join(editor.histórico, "|"); obtido != esperado {
+		t.Errorf("histórico\nesperado: %q; recebido: %q", esperado, obtido)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// teclas especiais do seletor, fora do intervalo de runas válidas
+const (
+	teclaSetaAcima rune = utf8.MaxRune + 1 + iota
+	teclaSetaAbaixo
+	teclaPáginaAcima
+	teclaPáginaAbaixo
+	teclaSozinhaEsc
+	teclaIgnorada
+)
+
+const (
+	teclaTab         = 9
+	alturaDetalhe    = 9
+	ajudaSeletor     = "Tab marca · Enter escolhe · Esc cancela"
+	estiloInvertido  = "\x1b[7m"
+	estiloTênue      = "\x1b[2m"
+	estiloNormal     = "\x1b[0m"
+	telaAlternativa  = "\x1b[?1049h"
+	telaPrincipal    = "\x1b[?1049l"
+	cursorInvisível  = "\x1b[?25l"
+	cursorVisível    = "\x1b[?25h"
+	limparAtéFimTela = "\x1b[J"
+)
+
+// errCancelado indica que o usuário saiu do seletor sem escolher nada
+var errCancelado = errors.New("seleção cancelada")
+
+type itemSeletor struct {
+	caractere Caractere
+	palavras  []string
+}
+
+// seletor mantém o estado da interface de seleção incremental
+type seletor struct {
+	itens      []itemSeletor
+	consulta   []rune
+	resultados []Caractere
+	cursor     int
+	topo       int
+	marcados   []rune
+}
+
+func novoSeletor(linhas []string, consulta string) *seletor {
+	s := &seletor{consulta: []rune(consulta)}
+	s.itens = make([]itemSeletor, len(linhas))
+	for i, linha := range linhas {
+		_, _, palavras := AnalisarLinha(linha)
+		s.itens[i] = itemSeletor{AnalisarCaractere(linha), palavras}
+	}
+	s.filtrar()
+	return s
+}
+
+func contémPrefixo(fatia []string, prefixo string) bool {
+	for _, item := range fatia {
+		if strings.HasPrefix(item, prefixo) {
+			return true
+		}
+	}
+	return false
+}
+
+// filtrar aplica a consulta: a última palavra, ainda sendo digitada,
+// é tratada como prefixo
+func (s *seletor) filtrar() {
+	consulta := strings.ToUpper(string(s.consulta))
+	termos := separar(consulta)
+	prefixo := ""
+	if len(termos) > 0 && !strings.HasSuffix(consulta, " ") && !strings.HasSuffix(consulta, "-") {
+		prefixo = termos[len(termos)-1]
+		termos = termos[:len(termos)-1]
+	}
+	s.resultados = s.resultados[:0]
+	for _, item := range s.itens {
+		if contémTodos(item.palavras, termos) && (prefixo == "" || contémPrefixo(item.palavras, prefixo)) {
+			s.resultados = append(s.resultados, item.caractere)
+		}
+	}
+	s.cursor, s.topo = 0, 0
+}
+
+func (s *seletor) marcado(r rune) int {
+	for i, m := range s.marcados {
+		if m == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *seletor) mover(delta int) {
+	s.cursor += delta
+	if s.cursor >= len(s.resultados) {
+		s.cursor = len(s.resultados) - 1
+	}
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+}
+
+// escolhidos devolve os caracteres marcados ou, se nenhum, o atual
+func (s *seletor) escolhidos() []Caractere {
+	if len(s.marcados) == 0 {
+		if len(s.resultados) == 0 {
+			return nil
+		}
+		return []Caractere{s.resultados[s.cursor]}
+	}
+	caracteres := make([]Caractere, len(s.marcados))
+	for i, r := range s.marcados {
+		for _, item := range s.itens {
+			if item.caractere.Runa == r {
+				caracteres[i] = item.caractere
+				break
+			}
+		}
+	}
+	return caracteres
+}
+
+// processar trata uma tecla; devolve true quando a seleção terminou
+func (s *seletor) processar(tecla rune, alturaLista int) (bool, error) {
+	switch tecla {
+	case teclaEnter, teclaNovaLinha:
+		if len(s.escolhidos()) == 0 {
+			return false, nil
+		}
+		return true, nil
+	case teclaCtrlC, teclaSozinhaEsc:
+		return true, errCancelado
+	case teclaSetaAcima, teclaCtrlP:
+		s.mover(-1)
+	case teclaSetaAbaixo, teclaCtrlN:
+		s.mover(1)
+	case teclaPáginaAcima:
+		s.mover(-alturaLista)
+	case teclaPáginaAbaixo:
+		s.mover(alturaLista)
+	case teclaTab:
+		if len(s.resultados) > 0 {
+			runa := s.resultados[s.cursor].Runa
+			if i := s.marcado(runa); i >= 0 {
+				s.marcados = append(s.marcados[:i], s.marcados[i+1:]...)
+			} else {
+				s.marcados = append(s.marcados, runa)
+			}
+			s.mover(1)
+		}
+	case teclaApagar, teclaCtrlH:
+		if len(s.consulta) > 0 {
+			s.consulta = s.consulta[:len(s.consulta)-1]
+			s.filtrar()
+		}
+	case teclaCtrlU:
+		s.consulta = s.consulta[:0]
+		s.filtrar()
+	default:
+		if tecla >= ' ' && tecla <= utf8.MaxRune {
+			s.consulta = append(s.consulta, tecla)
+			s.filtrar()
+		}
+	}
+	return false, nil
+}
+
+func truncar(texto string, largura int) string {
+	if largura <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(texto) <= largura {
+		return texto
+	}
+	return string([]rune(texto)[:largura-1]) + "…"
+}
+
+func alturaLista(altura int) int {
+	if h := altura - 2 - alturaDetalhe; h > 0 {
+		return h
+	}
+	return 1
+}
+
+// desenhar escreve a tela completa do seletor para um terminal com as
+// dimensões informadas
+func (s *seletor) desenhar(w io.Writer, largura, altura int) {
+	lista := alturaLista(altura)
+	if s.cursor < s.topo {
+		s.topo = s.cursor
+	}
+	if s.cursor >= s.topo+lista {
+		s.topo = s.cursor - lista + 1
+	}
+	fmt.Fprintf(w, "\x1b[H> %s\x1b[K\r\n", truncar(string(s.consulta), largura-2))
+	status := fmt.Sprintf("  %d/%d", len(s.resultados), len(s.itens))
+	if len(s.marcados) > 0 {
+		status += fmt.Sprintf(" (%d marcados)", len(s.marcados))
+	}
+	status += "  " + ajudaSeletor
+	fmt.Fprintf(w, "%s%s%s\x1b[K\r\n", estiloTênue, truncar(status, largura), estiloNormal)
+	for i := s.topo; i < s.topo+lista; i++ {
+		if i < len(s.resultados) {
+			c := s.resultados[i]
+			marca := " "
+			if s.marcado(c.Runa) >= 0 {
+				marca = "*"
+			}
+			texto := truncar(fmt.Sprintf("%s U+%04X  %c  %s", marca, c.Runa, c.Runa, c.NomeCompleto()), largura)
+			if i == s.cursor {
+				texto = estiloInvertido + texto + estiloNormal
+			}
+			fmt.Fprint(w, texto)
+		}
+		fmt.Fprint(w, "\x1b[K\r\n")
+	}
+	fmt.Fprint(w, strings.Repeat("─", largura)+"\r\n")
+	if len(s.resultados) > 0 {
+		detalhe := strings.Split(strings.TrimRight(Detalhar(s.resultados[s.cursor]), "\n"), "\n")
+		for i, linha := range detalhe {
+			if i >= alturaDetalhe-2 {
+				break
+			}
+			fmt.Fprintf(w, "%s\x1b[K\r\n", truncar(linha, largura))
+		}
+	}
+	fmt.Fprint(w, limparAtéFimTela)
+	fmt.Fprintf(w, "\x1b[1;%dH", utf8.RuneCountInString(string(s.consulta))+3)
+}
+
+// lerTecla lê uma tecla do terminal, traduzindo sequências de escape
+func lerTecla(entrada *bufio.Reader) (rune, error) {
+	r, _, err := entrada.ReadRune()
+	if err != nil || r != teclaEsc {
+		return r, err
+	}
+	if entrada.Buffered() == 0 {
+		return teclaSozinhaEsc, nil
+	}
+	switch lerSequênciaEsc(entrada) {
+	case 'A':
+		return teclaSetaAcima, nil
+	case 'B':
+		return teclaSetaAbaixo, nil
+	case '5':
+		return teclaPáginaAcima, nil
+	case '6':
+		return teclaPáginaAbaixo, nil
+	}
+	return teclaIgnorada, nil
+}
+
+// Escolher abre uma interface de tela cheia no terminal que filtra os
+// caracteres enquanto a consulta é digitada, e devolve os escolhidos.
+func Escolher(linhas []string, consulta string) ([]Caractere, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("terminal não disponível: %v", err)
+	}
+	defer tty.Close()
+	fd := int(tty.Fd())
+	restaurar, err := modoBruto(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer restaurar()
+
+	saída := bufio.NewWriter(tty)
+	fmt.Fprint(saída, telaAlternativa)
+	defer func() {
+		fmt.Fprint(saída, telaPrincipal+cursorVisível)
+		saída.Flush()
+	}()
+
+	s := novoSeletor(linhas, consulta)
+	entrada := bufio.NewReader(tty)
+	for {
+		largura, altura, err := tamanhoTerminal(fd)
+		if err != nil {
+			largura, altura = 80, 24
+		}
+		fmt.Fprint(saída, cursorInvisível)
+		s.desenhar(saída, largura, altura)
+		fmt.Fprint(saída, cursorVisível)
+		if err := saída.Flush(); err != nil {
+			return nil, err
+		}
+		tecla, err := lerTecla(entrada)
+		if err != nil {
+			return nil, err
+		}
+		fim, err := s.processar(tecla, alturaLista(altura))
+		if err != nil {
+			return nil, err
+		}
+		if fim {
+			return s.escolhidos(), nil
+		}
+	}
+}
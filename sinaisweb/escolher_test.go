@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func runasDe(caracteres []Caractere) string {
+	runas := []rune{}
+	for _, c := range caracteres {
+		runas = append(runas, c.Runa)
+	}
+	return string(runas)
+}
+
+func TestSeletorFiltrar(t *testing.T) {
+	casos := []struct {
+		consulta string
+		esperado string
+	}{
+		{"", "=>?@ABC"},
+		{"SI", "=>"},
+		{"sign", "=>"},
+		{"LATIN C", "ABC"},
+		{"LETTER A ", "A"},
+		{"GREATER-T", ">"},
+		{"XYZ", ""},
+	}
+	for _, caso := range casos {
+		s := novoSeletor(carregar(strings.NewReader(linhas3Da43)), caso.consulta)
+		if obtido := runasDe(s.resultados); obtido != caso.esperado {
+			t.Errorf("seletor(%q)\nesperado: %q; recebido: %q", caso.consulta, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestSeletorProcessar(t *testing.T) {
+	s := novoSeletor(carregar(strings.NewReader(linhas3Da43)), "")
+	for _, tecla := range "latin" {
+		s.processar(tecla, 10)
+	}
+	s.processar(teclaSetaAbaixo, 10)
+	s.processar(teclaTab, 10)
+	s.processar(teclaSetaAcima, 10)
+	s.processar(teclaSetaAcima, 10)
+	s.processar(teclaTab, 10)
+	fim, err := s.processar(teclaEnter, 10)
+	if !fim || err != nil {
+		t.Fatalf("Enter deveria encerrar a seleção: %v, %v", fim, err)
+	}
+	if obtido := runasDe(s.escolhidos()); obtido != "BA" {
+		t.Errorf("escolhidos\nesperado: %q; recebido: %q", "BA", obtido)
+	}
+	if _, err := s.processar(teclaSozinhaEsc, 10); err != errCancelado {
+		t.Errorf("Esc deveria cancelar; recebido: %v", err)
+	}
+}
+
+func TestSeletorSemResultados(t *testing.T) {
+	s := novoSeletor(carregar(strings.NewReader(linhas3Da43)), "XYZ")
+	if fim, _ := s.processar(teclaEnter, 10); fim {
+		t.Errorf("Enter sem resultados não deveria encerrar a seleção")
+	}
+	s.desenhar(io.Discard, 80, 24)
+}
+
+func TestLerTecla(t *testing.T) {
+	entrada := bufio.NewReader(strings.NewReader("a\x1b[A\x1b[B\x1b[6~\x1b"))
+	for _, esperado := range []rune{'a', teclaSetaAcima, teclaSetaAbaixo, teclaPáginaAbaixo, teclaSozinhaEsc} {
+		if obtido, err := lerTecla(entrada); obtido != esperado || err != nil {
+			t.Errorf("lerTecla\nesperado: %q; recebido: %q, %v", esperado, obtido, err)
+		}
+	}
+}
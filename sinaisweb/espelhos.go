@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// espelhosPadrão são usados quando nenhum espelho foi configurado. Todo
+// espelho segue a estrutura de diretórios de https://www.unicode.org/Public/,
+// com UCD/latest/ucd/ para a versão mais recente e 15.1.0/ucd/ para as demais.
+var espelhosPadrão = []string{"https://www.unicode.org/Public/"}
+
+// relativoUCD é o caminho do UnicodeData.txt mais recente em um espelho
+const relativoUCD = "UCD/latest/ucd/UnicodeData.txt"
+
+// diretórioLocal devolve o diretório de um espelho file:// ou de um caminho
+// local, que servem para repositórios internos de artefatos
+func diretórioLocal(espelho string) (string, bool) {
+	if strings.HasPrefix(espelho, "file://") {
+		u, err := url.Parse(espelho)
+		if err != nil {
+			return "", false
+		}
+		return filepath.FromSlash(u.Path), true
+	}
+	return espelho, !strings.Contains(espelho, "://")
+}
+
+// juntarEspelho devolve o endereço do caminho relativo no espelho
+func juntarEspelho(espelho, relativo string) string {
+	if diretório, ok := diretórioLocal(espelho); ok {
+		return filepath.Join(diretório, filepath.FromSlash(relativo))
+	}
+	return strings.TrimSuffix(espelho, "/") + "/" + relativo
+}
+
+// copiarDeEspelhoLocal valida o arquivo do espelho local antes de colocá-lo
+// no caminho final, com as mesmas garantias de baixarAtomicamente
+func copiarDeEspelhoLocal(origem, caminho string, opções opçõesDownload) error {
+	parcial := caminho + ".parcial"
+	err := copiarArquivo(origem, parcial)
+	if err == nil {
+		err = validarArquivo(origem, filepath.Base(caminho), parcial, opções)
+	}
+	if err != nil {
+		os.Remove(parcial)
+		return err
+	}
+	return os.Rename(parcial, caminho)
+}
+
+// baixarDoEspelho obtém o arquivo de um espelho, exibindo o andamento na
+// saída de erros se ele for remoto
+func baixarDoEspelho(ctx context.Context, origem, caminho string, opções opçõesDownload) error {
+	if _, ok := diretórioLocal(origem); ok {
+		return copiarDeEspelhoLocal(origem, caminho, opções)
+	}
+	opções.andamento = novoAndamento()
+	if opções.Progresso != progressoSilencioso {
+		fmt.Fprintf(os.Stderr, "baixando %s para %s\n", origem, caminho)
+	}
+	feito := make(chan error)
+	go baixarUCD(ctx, origem, caminho, opções, feito)
+	return progresso(feito, opções.andamento, os.Stderr, opções.Progresso)
+}
+
+// baixarDeEspelhos tenta os espelhos em ordem até que um deles forneça o
+// arquivo, e devolve o endereço usado. Ctrl-C cancela o download e remove
+// o arquivo parcial.
+func baixarDeEspelhos(relativo, caminho string, opções opçõesDownload) (string, error) {
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer parar()
+	opções = opções.comPadrões()
+	if opções.Inseguro && opções.Progresso != progressoSilencioso {
+		slog.Warn("certificados TLS não serão verificados")
+	}
+	erros := []error{}
+	for i, espelho := range opções.Espelhos {
+		origem := juntarEspelho(espelho, relativo)
+		err := baixarDoEspelho(ctx, origem, caminho, opções)
+		if err == nil {
+			return origem, nil
+		}
+		if errors.Is(err, ErrDownloadCancelado) || errors.Is(err, ErrNãoModificado) {
+			return "", err
+		}
+		// um download parcial não pode ser retomado de outro espelho
+		descartarParcial(caminho + ".parcial")
+		erros = append(erros, err)
+		if i < len(opções.Espelhos)-1 && opções.Progresso != progressoSilencioso {
+			slog.Warn("tentando o próximo espelho", "erro", err)
+		}
+	}
+	return "", errors.Join(erros...)
+}
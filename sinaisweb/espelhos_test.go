@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJuntarEspelho(t *testing.T) {
+	casos := []struct {
+		espelho  string
+		esperado string
+	}{
+		{"https://www.unicode.org/Public/", "https://www.unicode.org/Public/15.1.0/ucd/UCD.zip"},
+		{"https://artefatos.exemplo/ucd", "https://artefatos.exemplo/ucd/15.1.0/ucd/UCD.zip"},
+		{"file:///srv/ucd", "/srv/ucd/15.1.0/ucd/UCD.zip"},
+		{"/srv/ucd/", "/srv/ucd/15.1.0/ucd/UCD.zip"},
+	}
+	for _, caso := range casos {
+		if obtido := juntarEspelho(caso.espelho, "15.1.0/ucd/UCD.zip"); obtido != caso.esperado {
+			t.Errorf("juntarEspelho(%q)\nesperado: %q; recebido: %q", caso.espelho, caso.esperado, obtido)
+		}
+	}
+}
+
+// espelhoLocal cria um diretório com a estrutura de um espelho contendo
+// o UnicodeData.txt mais recente
+func espelhoLocal(t *testing.T) string {
+	t.Helper()
+	diretório := t.TempDir()
+	caminho := juntarEspelho(diretório, relativoUCD)
+	os.MkdirAll(filepath.Dir(caminho), 0o755)
+	os.WriteFile(caminho, []byte(linhas3Da43), 0o644)
+	return diretório
+}
+
+func TestBaixarDeEspelhos_passaParaOPróximo(t *testing.T) {
+	fora := httptest.NewServer(http.NotFoundHandler())
+	defer fora.Close()
+	local := espelhoLocal(t)
+	opções := opçõesDownload{Espelhos: []string{fora.URL, "file://" + local}, Progresso: progressoSilencioso}
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	origem, err := baixarDeEspelhos(relativoUCD, caminho, opções)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if esperada := juntarEspelho(local, relativoUCD); origem != esperada {
+		t.Errorf("origem\nesperada: %q; recebida: %q", esperada, origem)
+	}
+	if conteúdo, _ := os.ReadFile(caminho); string(conteúdo) != linhas3Da43 {
+		t.Errorf("conteúdo copiado do espelho local difere do original")
+	}
+}
+
+func TestBaixarDeEspelhos_todosFalham(t *testing.T) {
+	fora := httptest.NewServer(http.NotFoundHandler())
+	defer fora.Close()
+	opções := opçõesDownload{Espelhos: []string{fora.URL, t.TempDir()}, Progresso: progressoSilencioso}
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	_, err := baixarDeEspelhos(relativoUCD, caminho, opções)
+	var status *ErroStatusHTTP
+	if !errors.As(err, &status) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("o erro deveria reunir as falhas dos dois espelhos; recebido: %v", err)
+	}
+	for _, resto := range []string{caminho, caminho + ".parcial"} {
+		if _, err := os.Stat(resto); !os.IsNotExist(err) {
+			t.Errorf("%s não deveria existir", filepath.Base(resto))
+		}
+	}
+}
+
+func TestCopiarDeEspelhoLocal_inválido(t *testing.T) {
+	origem := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	os.WriteFile(origem, []byte("<html>não é a UCD</html>"), 0o644)
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	var conteúdo *ErroConteúdo
+	if err := copiarDeEspelhoLocal(origem, caminho, opçõesDownload{}); !errors.As(err, &conteúdo) {
+		t.Errorf("erro esperado: ErroConteúdo; recebido: %v", err)
+	}
+	if _, err := os.Stat(caminho); !os.IsNotExist(err) {
+		t.Errorf("arquivo inválido não deveria ser copiado")
+	}
+}
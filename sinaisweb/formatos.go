@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// registro é a representação de um Caractere para formatos estruturados
+type registro struct {
+	Código       string `json:"codigo"`
+	Caractere    string `json:"caractere"`
+	Nome         string `json:"nome"`
+	NomeAntigo   string `json:"nome_antigo,omitempty"`
+	Categoria    string `json:"categoria,omitempty"`
+	Combinação   string `json:"combinacao,omitempty"`
+	Bidi         string `json:"bidi,omitempty"`
+	Decomposição string `json:"decomposicao,omitempty"`
+	Valor        string `json:"valor,omitempty"`
+	Espelhado    bool   `json:"espelhado,omitempty"`
+	Maiúscula    string `json:"maiuscula,omitempty"`
+	Minúscula    string `json:"minuscula,omitempty"`
+	Título       string `json:"titulo,omitempty"`
+}
+
+func códigoOpcional(r rune) string {
+	if r == 0 {
+		return ""
+	}
+	return fmt.Sprintf("U+%04X", r)
+}
+
+func novoRegistro(c Caractere) registro {
+	return registro{
+		Código:       fmt.Sprintf("U+%04X", c.Runa),
+		Caractere:    string(c.Runa),
+		Nome:         c.Nome,
+		NomeAntigo:   c.NomeAntigo,
+		Categoria:    c.Categoria,
+		Combinação:   c.Combinação,
+		Bidi:         c.Bidi,
+		Decomposição: c.Decomposição,
+		Valor:        c.ValorNumérico,
+		Espelhado:    c.Espelhado,
+		Maiúscula:    códigoOpcional(c.Maiúscula),
+		Minúscula:    códigoOpcional(c.Minúscula),
+		Título:       códigoOpcional(c.Título),
+	}
+}
+
+// NomeCompleto devolve o nome seguido do nome antigo entre parênteses
+func (c Caractere) NomeCompleto() string {
+	if c.NomeAntigo == "" {
+		return c.Nome
+	}
+	return fmt.Sprintf("%s (%s)", c.Nome, c.NomeAntigo)
+}
+
+func formatarTexto(w io.Writer, caracteres []Caractere) error {
+	for _, c := range caracteres {
+		if _, err := fmt.Fprintf(w, "U+%04X\t%[1]c\t%s\n", c.Runa, c.NomeCompleto()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatarCaractere(w io.Writer, caracteres []Caractere) error {
+	runas := make([]rune, len(caracteres))
+	for i, c := range caracteres {
+		runas[i] = c.Runa
+	}
+	_, err := fmt.Fprintln(w, string(runas))
+	return err
+}
+
+func formatarJSON(w io.Writer, caracteres []Caractere) error {
+	registros := make([]registro, len(caracteres))
+	for i, c := range caracteres {
+		registros[i] = novoRegistro(c)
+	}
+	codificador := json.NewEncoder(w)
+	codificador.SetIndent("", "  ")
+	return codificador.Encode(registros)
+}
+
+var formatadores = map[string]func(io.Writer, []Caractere) error{
+	"texto":     formatarTexto,
+	"caractere": formatarCaractere,
+	"json":      formatarJSON,
+}
+
+// nomesFormatos lista os formatos de saída aceitos, em ordem alfabética
+func nomesFormatos() string {
+	nomes := make([]string, 0, len(formatadores))
+	for nome := range formatadores {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return strings.Join(nomes, ", ")
+}
+
+// Formatar escreve os caracteres em w no formato pedido
+func Formatar(w io.Writer, caracteres []Caractere, formato string) error {
+	formatador, ok := formatadores[formato]
+	if !ok {
+		return fmt.Errorf("formato desconhecido: %q (use: %s)", formato, nomesFormatos())
+	}
+	return formatador(w, caracteres)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleFormatar() {
+	caracteres := Filtrar(carregar(strings.NewReader(linhas3Da43)), "MARK")
+	Formatar(os.Stdout, caracteres, "texto")
+	Formatar(os.Stdout, caracteres, "json")
+	// Output:
+	// U+003F	?	QUESTION MARK
+	// [
+	//   {
+	//     "codigo": "U+003F",
+	//     "caractere": "?",
+	//     "nome": "QUESTION MARK",
+	//     "categoria": "Po",
+	//     "combinacao": "0",
+	//     "bidi": "ON"
+	//   }
+	// ]
+}
+
+func TestFormatar_desconhecido(t *testing.T) {
+	if err := Formatar(os.Stdout, nil, "xml"); err == nil {
+		t.Errorf("Formatar com formato \"xml\" deveria devolver erro")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	limitesDuração    = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	limitesResultados = []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000}
+)
+
+// histograma conta as observações por faixa, no formato do Prometheus
+type histograma struct {
+	limites   []float64
+	contagens []uint64 // por faixa, sem acumular; a última é a de +Inf
+	soma      float64
+	total     uint64
+}
+
+func novoHistograma(limites []float64) *histograma {
+	return &histograma{limites: limites, contagens: make([]uint64, len(limites)+1)}
+}
+
+func (h *histograma) observar(valor float64) {
+	h.contagens[sort.SearchFloat64s(h.limites, valor)]++
+	h.soma += valor
+	h.total++
+}
+
+// escrever produz as linhas _bucket, _sum e _count da série
+func (h *histograma) escrever(w io.Writer, nome, rótulos string) {
+	acumulado := uint64(0)
+	for i, contagem := range h.contagens {
+		acumulado += contagem
+		le := "+Inf"
+		if i < len(h.limites) {
+			le = formatarNúmero(h.limites[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d\n", nome, rótulos, le, acumulado)
+	}
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", nome, rótulos, formatarNúmero(h.soma))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", nome, rótulos, h.total)
+}
+
+func formatarNúmero(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var escapeRótulo = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// rótulo formata um par nome="valor" com o escape do formato de texto
+func rótulo(nome, valor string) string {
+	return nome + `="` + escapeRótulo.Replace(valor) + `"`
+}
+
+type chaveRequisição struct {
+	rota   string
+	status int
+}
+
+// métricas acumula o que /metrics expõe
+type métricas struct {
+	mu          sync.Mutex
+	requisições map[chaveRequisição]uint64
+	durações    map[string]*histograma // por rota
+	resultados  map[string]*histograma // por rota
+	versão      string
+	caracteres  int
+	pronto      bool
+}
+
+func novasMétricas() *métricas {
+	return &métricas{
+		requisições: map[chaveRequisição]uint64{},
+		durações:    map[string]*histograma{},
+		resultados:  map[string]*histograma{},
+	}
+}
+
+func (m *métricas) observarRequisição(rota string, status int, duração time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requisições[chaveRequisição{rota, status}]++
+	h, ok := m.durações[rota]
+	if !ok {
+		h = novoHistograma(limitesDuração)
+		m.durações[rota] = h
+	}
+	h.observar(duração.Seconds())
+}
+
+// observarResultados registra quantos resultados uma consulta encontrou
+func (m *métricas) observarResultados(rota string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.resultados[rota]
+	if !ok {
+		h = novoHistograma(limitesResultados)
+		m.resultados[rota] = h
+	}
+	h.observar(float64(n))
+}
+
+// definirDados marca o servidor como pronto e registra a versão carregada
+func (m *métricas) definirDados(versão string, caracteres int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versão, m.caracteres, m.pronto = versão, caracteres, true
+}
+
+func ordenarChaves(mapa map[string]*histograma) []string {
+	chaves := make([]string, 0, len(mapa))
+	for chave := range mapa {
+		chaves = append(chaves, chave)
+	}
+	sort.Strings(chaves)
+	return chaves
+}
+
+// escrever expõe as métricas no formato de texto do Prometheus, em ordem
+// estável
+func (m *métricas) escrever(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP sinais_requisicoes_total Requisições HTTP atendidas, por rota e status.")
+	fmt.Fprintln(w, "# TYPE sinais_requisicoes_total counter")
+	chaves := make([]chaveRequisição, 0, len(m.requisições))
+	for chave := range m.requisições {
+		chaves = append(chaves, chave)
+	}
+	sort.Slice(chaves, func(i, j int) bool {
+		if chaves[i].rota != chaves[j].rota {
+			return chaves[i].rota < chaves[j].rota
+		}
+		return chaves[i].status < chaves[j].status
+	})
+	for _, chave := range chaves {
+		fmt.Fprintf(w, "sinais_requisicoes_total{%s,%s} %d\n",
+			rótulo("rota", chave.rota), rótulo("status", strconv.Itoa(chave.status)), m.requisições[chave])
+	}
+
+	fmt.Fprintln(w, "# HELP sinais_duracao_requisicao_segundos Tempo de resposta, por rota.")
+	fmt.Fprintln(w, "# TYPE sinais_duracao_requisicao_segundos histogram")
+	for _, rota := range ordenarChaves(m.durações) {
+		m.durações[rota].escrever(w, "sinais_duracao_requisicao_segundos", rótulo("rota", rota))
+	}
+
+	fmt.Fprintln(w, "# HELP sinais_resultados Resultados encontrados por consulta, por rota.")
+	fmt.Fprintln(w, "# TYPE sinais_resultados histogram")
+	for _, rota := range ordenarChaves(m.resultados) {
+		m.resultados[rota].escrever(w, "sinais_resultados", rótulo("rota", rota))
+	}
+
+	fmt.Fprintln(w, "# HELP sinais_dados_info Versão da UCD carregada.")
+	fmt.Fprintln(w, "# TYPE sinais_dados_info gauge")
+	if m.pronto {
+		fmt.Fprintf(w, "sinais_dados_info{%s} 1\n", rótulo("versao", m.versão))
+	}
+	fmt.Fprintln(w, "# HELP sinais_caracteres Caracteres carregados.")
+	fmt.Fprintln(w, "# TYPE sinais_caracteres gauge")
+	fmt.Fprintf(w, "sinais_caracteres %d\n", m.caracteres)
+	fmt.Fprintln(w, "# HELP sinais_pronto 1 quando os dados estão carregados.")
+	fmt.Fprintln(w, "# TYPE sinais_pronto gauge")
+	pronto := 0
+	if m.pronto {
+		pronto = 1
+	}
+	fmt.Fprintf(w, "sinais_pronto %d\n", pronto)
+}
+
+// gravadorResposta guarda o status enviado pelo tratador e o número de
+// resultados que ele anotou, para as métricas e o log de acesso
+type gravadorResposta struct {
+	http.ResponseWriter
+	status     int
+	resultados int // -1 se a rota não faz consultas
+}
+
+func (g *gravadorResposta) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gravadorResposta) Write(p []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+func (g *gravadorResposta) Unwrap() http.ResponseWriter { return g.ResponseWriter }
+
+// anotarResultados informa quantos resultados a consulta encontrou,
+// procurando o gravadorResposta sob os demais gravadores
+func anotarResultados(w http.ResponseWriter, n int) {
+	for {
+		if g, ok := w.(*gravadorResposta); ok {
+			g.resultados = n
+			return
+		}
+		embrulho, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = embrulho.Unwrap()
+	}
+}
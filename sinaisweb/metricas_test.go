@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHistograma_escrever(t *testing.T) {
+	h := novoHistograma([]float64{1, 5})
+	for _, valor := range []float64{0, 1, 3, 7.5} {
+		h.observar(valor)
+	}
+	var saída bytes.Buffer
+	h.escrever(&saída, "x", `rota="/"`)
+	esperado := `x_bucket{rota="/",le="1"} 2
+x_bucket{rota="/",le="5"} 3
+x_bucket{rota="/",le="+Inf"} 4
+x_sum{rota="/"} 11.5
+x_count{rota="/"} 4
+`
+	if saída.String() != esperado {
+		t.Errorf("esperado:\n%s\nrecebido:\n%s", esperado, saída.String())
+	}
+}
+
+func TestRótulo(t *testing.T) {
+	casos := []struct {
+		valor    string
+		esperado string
+	}{
+		{"/api/v1/busca", `rota="/api/v1/busca"`},
+		{`a"b\c`, `rota="a\"b\\c"`},
+		{"a\nb", `rota="a\nb"`},
+	}
+	for _, caso := range casos {
+		if obtido := rótulo("rota", caso.valor); obtido != caso.esperado {
+			t.Errorf("rótulo(%q)\nesperado: %s; recebido: %s", caso.valor, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestMétricas_antesDaCarga(t *testing.T) {
+	var saída bytes.Buffer
+	novasMétricas().escrever(&saída)
+	if !strings.Contains(saída.String(), "sinais_pronto 0\n") || strings.Contains(saída.String(), "sinais_dados_info{") {
+		t.Errorf("métricas antes da carga:\n%s", saída.String())
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const tipoOpenSearch = "application/opensearchdescription+xml"
+
+// descriçãoOpenSearch é o documento que permite aos navegadores usar o
+// sinais como mecanismo de busca, com sugestões na barra de endereços
+type descriçãoOpenSearch struct {
+	XMLName       xml.Name        `xml:"OpenSearchDescription"`
+	Xmlns         string          `xml:"xmlns,attr"`
+	ShortName     string          `xml:"ShortName"`
+	Description   string          `xml:"Description"`
+	InputEncoding string          `xml:"InputEncoding"`
+	URLs          []urlOpenSearch `xml:"Url"`
+}
+
+type urlOpenSearch struct {
+	Tipo   string `xml:"type,attr"`
+	Método string `xml:"method,attr"`
+	Modelo string `xml:"template,attr"`
+}
+
+// baseDaRequisição devolve o endereço público configurado ou reconstrói
+// aquele pelo qual o cliente chegou ao servidor, já que os modelos do
+// OpenSearch precisam ser absolutos. O X-Forwarded-Proto só é aceito de
+// proxies confiáveis.
+func baseDaRequisição(r *http.Request, opções opçõesServidor) string {
+	if opções.URLPública != "" {
+		return opções.URLPública
+	}
+	esquema := "http"
+	if r.TLS != nil || (opções.confiável(endereçoCliente(r)) && r.Header.Get("X-Forwarded-Proto") == "https") {
+		esquema = "https"
+	}
+	return esquema + "://" + r.Host
+}
+
+// fazOpenSearch devolve o tratador da descrição em /opensearch.xml; ele
+// não passa pelo cache porque o conteúdo pode depender do Host da
+// requisição
+func fazOpenSearch(opções opçõesServidor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		servirOpenSearch(w, baseDaRequisição(r, opções))
+	}
+}
+
+// servirOpenSearch escreve a descrição com os modelos sob a base
+func servirOpenSearch(w http.ResponseWriter, base string) {
+	descrição := descriçãoOpenSearch{
+		Xmlns:         "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:     "sinais",
+		Description:   "Busca caracteres Unicode pelo nome",
+		InputEncoding: "UTF-8",
+		URLs: []urlOpenSearch{
+			{"text/html", "get", base + "/?consulta={searchTerms}"},
+			{"application/x-suggestions+json", "get", base + "/api/v1/sugerir?formato=opensearch&prefixo={searchTerms}"},
+		},
+	}
+	corpo, err := xml.MarshalIndent(descrição, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", tipoOpenSearch+"; charset=utf-8")
+	fmt.Fprintf(w, "%s%s\n", xml.Header, corpo)
+}
+
+// completarConsulta troca a última palavra da consulta pela palavra
+// sugerida, como faz o script da caixa de busca. A palavra vem em
+// minúsculas se o usuário digitou em minúsculas, para não misturar
+// "smiling CAPITAL" na caixa de busca.
+func completarConsulta(consulta, palavra string) string {
+	início := strings.LastIndexAny(consulta, " -") + 1
+	if digitado := consulta[início:]; digitado == strings.ToLower(digitado) {
+		palavra = strings.ToLower(palavra)
+	}
+	return consulta[:início] + palavra
+}
+
+// responderSugestõesOpenSearch usa o formato da extensão de sugestões:
+// [consulta, [completamentos], [descrições]]. Um nome igual a uma palavra
+// completada não é repetido; só empresta a ela sua descrição.
+func responderSugestõesOpenSearch(w http.ResponseWriter, prefixo string, palavras []string, nomes []Caractere) {
+	completamentos, descrições := []string{}, []string{}
+	for _, palavra := range palavras {
+		completamentos = append(completamentos, completarConsulta(prefixo, palavra))
+		descrições = append(descrições, "")
+	}
+	for _, c := range nomes {
+		descrição := fmt.Sprintf("U+%04X %s", c.Runa, string(c.Runa))
+		repetido := false
+		for i, completamento := range completamentos {
+			if strings.EqualFold(completamento, c.Nome) {
+				descrições[i], repetido = descrição, true
+				break
+			}
+		}
+		if !repetido {
+			completamentos = append(completamentos, c.Nome)
+			descrições = append(descrições, descrição)
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-suggestions+json; charset=utf-8")
+	json.NewEncoder(w).Encode([]interface{}{prefixo, completamentos, descrições})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServirOpenSearch(t *testing.T) {
+	confiáveis, _ := analisarRedes([]string{"10.0.0.0/8"})
+	casos := []struct {
+		opções opçõesServidor
+		origem string
+		proto  string
+		base   string
+	}{
+		{opçõesServidor{}, "192.0.2.1:1234", "", "http://sinais.intranet:8080"},
+		{opçõesServidor{}, "192.0.2.1:1234", "https", "http://sinais.intranet:8080"},
+		{opçõesServidor{ProxiesConfiáveis: confiáveis}, "10.1.2.3:1234", "https", "https://sinais.intranet:8080"},
+		{opçõesServidor{URLPública: "https://sinais.exemplo.org"}, "192.0.2.1:1234", "", "https://sinais.exemplo.org"},
+	}
+	for _, caso := range casos {
+		s := novoServiço(caso.opções)
+		s.carregar(novosDadosDeTeste(), "15.1.0")
+		requisição := httptest.NewRequest("GET", "http://sinais.intranet:8080/opensearch.xml", nil)
+		requisição.RemoteAddr = caso.origem
+		if caso.proto != "" {
+			requisição.Header.Set("X-Forwarded-Proto", caso.proto)
+		}
+		gravador := httptest.NewRecorder()
+		s.ServeHTTP(gravador, requisição)
+		if tipo := gravador.Header().Get("Content-Type"); gravador.Code != http.StatusOK || !strings.HasPrefix(tipo, tipoOpenSearch) {
+			t.Fatalf("GET /opensearch.xml: %d %s", gravador.Code, tipo)
+		}
+		var descrição descriçãoOpenSearch
+		if err := xml.Unmarshal(gravador.Body.Bytes(), &descrição); err != nil {
+			t.Fatalf("XML inválido: %v\n%s", err, gravador.Body.String())
+		}
+		esperado := []urlOpenSearch{
+			{"text/html", "get", caso.base + "/?consulta={searchTerms}"},
+			{"application/x-suggestions+json", "get", caso.base + "/api/v1/sugerir?formato=opensearch&prefixo={searchTerms}"},
+		}
+		if descrição.ShortName != "sinais" || !reflect.DeepEqual(descrição.URLs, esperado) {
+			t.Errorf("descrição OpenSearch de %s com X-Forwarded-Proto %q\nesperado: %+v\nrecebido: %+v",
+				caso.origem, caso.proto, esperado, descrição)
+		}
+	}
+}
+
+func TestPáginaLigaOpenSearch(t *testing.T) {
+	_, corpo := requisitarPágina(fazRespondedor(novosDadosDeTeste()), "/")
+	ligação := `<link rel="search" type="application/opensearchdescription+xml" title="sinais" href="/opensearch.xml">`
+	if !strings.Contains(corpo, ligação) {
+		t.Errorf("página deveria conter %q:\n%s", ligação, corpo)
+	}
+}
+
+func TestAPI_sugerirOpenSearch(t *testing.T) {
+	gravador := httptest.NewRecorder()
+	a := novaAPIDeTeste(t)
+	a.ServeHTTP(gravador, httptest.NewRequest("GET", "/api/v1/sugerir?formato=opensearch&prefixo=greater-t", nil))
+	if tipo := gravador.Header().Get("Content-Type"); !strings.HasPrefix(tipo, "application/x-suggestions+json") {
+		t.Errorf("Content-Type: %q", tipo)
+	}
+	var resposta []interface{}
+	if err := json.Unmarshal(gravador.Body.Bytes(), &resposta); err != nil {
+		t.Fatal(err)
+	}
+	esperado := []interface{}{
+		"greater-t",
+		[]interface{}{"greater-than", "GREATER-THAN SIGN"},
+		[]interface{}{"", "U+003E >"},
+	}
+	if !reflect.DeepEqual(resposta, esperado) {
+		t.Errorf("sugestões OpenSearch\nesperado: %v\nrecebido: %v", esperado, resposta)
+	}
+
+	// a palavra AT completada forma "commercial AT", que também é um nome
+	gravador = httptest.NewRecorder()
+	a.ServeHTTP(gravador, httptest.NewRequest("GET", "/api/v1/sugerir?formato=opensearch&prefixo=commercial+a", nil))
+	json.Unmarshal(gravador.Body.Bytes(), &resposta)
+	esperado = []interface{}{
+		"commercial a",
+		[]interface{}{"commercial a", "commercial at"},
+		[]interface{}{"", "U+0040 @"},
+	}
+	if !reflect.DeepEqual(resposta, esperado) {
+		t.Errorf("nome repetido\nesperado: %v\nrecebido: %v", esperado, resposta)
+	}
+
+	// várias palavras em minúsculas não ganham a completada em maiúsculas
+	gravador = httptest.NewRecorder()
+	a.ServeHTTP(gravador, httptest.NewRequest("GET", "/api/v1/sugerir?formato=opensearch&prefixo=latin+cap", nil))
+	json.Unmarshal(gravador.Body.Bytes(), &resposta)
+	esperado = []interface{}{
+		"latin cap",
+		[]interface{}{"latin capital", "LATIN CAPITAL LETTER A", "LATIN CAPITAL LETTER B", "LATIN CAPITAL LETTER C"},
+		[]interface{}{"", "U+0041 A", "U+0042 B", "U+0043 C"},
+	}
+	if !reflect.DeepEqual(resposta, esperado) {
+		t.Errorf("consulta de várias palavras\nesperado: %v\nrecebido: %v", esperado, resposta)
+	}
+}
+
+func TestCompletarConsulta(t *testing.T) {
+	casos := []struct {
+		consulta, palavra, esperado string
+	}{
+		{"sm", "SMILING", "smiling"},
+		{"cat sm", "SMILING", "cat smiling"},
+		{"greater-t", "THAN", "greater-than"},
+		{"cat SM", "SMILING", "cat SMILING"},
+		{"CAT Sm", "SMILING", "CAT SMILING"},
+		{"cat 1", "1ST", "cat 1st"},
+	}
+	for _, caso := range casos {
+		if obtido := completarConsulta(caso.consulta, caso.palavra); obtido != caso.esperado {
+			t.Errorf("completarConsulta(%q, %q)\nesperado: %q; recebido: %q", caso.consulta, caso.palavra, caso.esperado, obtido)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	limitePadrão       = 50  // resultados por página quando o cliente não informa
+	páginaMáximaPadrão = 200 // maior limite aceito do cliente
+)
+
+// dadosWeb reúne o que as páginas HTML e a API consultam
+type dadosWeb struct {
+	linhas       []string
+	blocos       []bloco // nil se o Blocks.txt não estiver disponível
+	sinônimos    map[string]string
+	páginaMáxima int
+	sugestões    *sugestor
+	consultas    *cacheConsultas // nil para não guardar resultados
+	identidade   string          // de identificarDados, para as ETags
+}
+
+// paginação delimita a parte dos resultados incluída em uma resposta
+type paginação struct {
+	Total        int    `json:"total"`
+	Limite       int    `json:"limite"`
+	Deslocamento int    `json:"deslocamento"`
+	Próxima      string `json:"proxima,omitempty"`
+	Anterior     string `json:"anterior,omitempty"`
+}
+
+func lerInteiro(r *http.Request, nome string, padrão, mínimo int) (int, error) {
+	valor := r.URL.Query().Get(nome)
+	if valor == "" {
+		return padrão, nil
+	}
+	n, err := strconv.Atoi(valor)
+	if err != nil || n < mínimo {
+		return 0, fmt.Errorf("%s deve ser um inteiro maior ou igual a %d: %q", nome, mínimo, valor)
+	}
+	return n, nil
+}
+
+// paginar lê os parâmetros limite e deslocamento da requisição; limites
+// acima do máximo do servidor são reduzidos a ele
+func paginar(r *http.Request, total, máximo int) (paginação, error) {
+	padrão := limitePadrão
+	if padrão > máximo {
+		padrão = máximo
+	}
+	limite, err := lerInteiro(r, "limite", padrão, 1)
+	if err != nil {
+		return paginação{}, err
+	}
+	deslocamento, err := lerInteiro(r, "deslocamento", 0, 0)
+	if err != nil {
+		return paginação{}, err
+	}
+	if limite > máximo {
+		limite = máximo
+	}
+	p := paginação{Total: total, Limite: limite, Deslocamento: deslocamento}
+	if deslocamento < total-limite { // deslocamento+limite pode transbordar
+		p.Próxima = endereçoDaPágina(r, deslocamento+limite, limite)
+	}
+	if deslocamento > 0 {
+		anterior := deslocamento - limite
+		if anterior < 0 {
+			anterior = 0
+		}
+		p.Anterior = endereçoDaPágina(r, anterior, limite)
+	}
+	return p, nil
+}
+
+// endereçoDaPágina repete a requisição com outro deslocamento
+func endereçoDaPágina(r *http.Request, deslocamento, limite int) string {
+	u := *r.URL
+	parâmetros := u.Query()
+	parâmetros.Set("deslocamento", strconv.Itoa(deslocamento))
+	parâmetros.Set("limite", strconv.Itoa(limite))
+	u.RawQuery = parâmetros.Encode()
+	return u.RequestURI()
+}
+
+// intervalo devolve os índices inicial e final da página nos resultados
+func (p paginação) intervalo() (int, int) {
+	início := p.Deslocamento
+	if início > p.Total {
+		início = p.Total
+	}
+	fim := início + p.Limite
+	if fim > p.Total {
+		fim = p.Total
+	}
+	return início, fim
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPaginar(t *testing.T) {
+	casos := []struct {
+		alvo     string
+		total    int
+		esperado paginação
+	}{
+		{"/?q=a", 10, paginação{Total: 10, Limite: 5, Deslocamento: 0, Próxima: "/?deslocamento=5&limite=5&q=a"}},
+		{"/?q=a&limite=3&deslocamento=3", 10, paginação{Total: 10, Limite: 3, Deslocamento: 3,
+			Próxima: "/?deslocamento=6&limite=3&q=a", Anterior: "/?deslocamento=0&limite=3&q=a"}},
+		{"/?limite=1000&deslocamento=8", 10, paginação{Total: 10, Limite: 5, Deslocamento: 8,
+			Anterior: "/?deslocamento=3&limite=5"}},
+		{"/?deslocamento=2&limite=4", 3, paginação{Total: 3, Limite: 4, Deslocamento: 2,
+			Anterior: "/?deslocamento=0&limite=4"}},
+		{"/?deslocamento=9223372036854775800", 10, paginação{Total: 10, Limite: 5, Deslocamento: 9223372036854775800,
+			Anterior: "/?deslocamento=9223372036854775795&limite=5"}},
+	}
+	for _, caso := range casos {
+		obtido, err := paginar(httptest.NewRequest("GET", caso.alvo, nil), caso.total, 5)
+		if err != nil {
+			t.Errorf("paginar(%s): %v", caso.alvo, err)
+			continue
+		}
+		if !reflect.DeepEqual(obtido, caso.esperado) {
+			t.Errorf("paginar(%s)\nesperado: %+v\nrecebido: %+v", caso.alvo, caso.esperado, obtido)
+		}
+	}
+	for _, alvo := range []string{"/?limite=0", "/?limite=x", "/?deslocamento=-1"} {
+		if _, err := paginar(httptest.NewRequest("GET", alvo, nil), 10, 5); err == nil {
+			t.Errorf("paginar(%s) deveria devolver erro", alvo)
+		}
+	}
+}
+
+func TestPaginaçãoIntervalo(t *testing.T) {
+	casos := []struct {
+		p           paginação
+		início, fim int
+	}{
+		{paginação{Total: 10, Limite: 3, Deslocamento: 0}, 0, 3},
+		{paginação{Total: 10, Limite: 3, Deslocamento: 9}, 9, 10},
+		{paginação{Total: 10, Limite: 3, Deslocamento: 20}, 10, 10},
+	}
+	for _, caso := range casos {
+		if início, fim := caso.p.intervalo(); início != caso.início || fim != caso.fim {
+			t.Errorf("%+v.intervalo()\nesperado: %d, %d; recebido: %d, %d", caso.p, caso.início, caso.fim, início, fim)
+		}
+	}
+}
+
+func TestAPI_buscaPaginada(t *testing.T) {
+	status, corpo := requisitar(t, novaAPIDeTeste(t), "GET", "/api/v1/busca?q=latin&limite=2&deslocamento=1")
+	if status != http.StatusOK {
+		t.Fatalf("status: %d %v", status, corpo)
+	}
+	resultados := corpo["resultados"].([]interface{})
+	if corpo["total"] != float64(3) || len(resultados) != 2 || corpo["limite"] != float64(2) || corpo["deslocamento"] != float64(1) {
+		t.Errorf("busca paginada: %v", corpo)
+	}
+	if primeiro := resultados[0].(map[string]interface{}); primeiro["codigo"] != "U+0042" {
+		t.Errorf("primeiro resultado da página\nesperado: U+0042; recebido: %v", primeiro["codigo"])
+	}
+	if corpo["anterior"] != "/api/v1/busca?deslocamento=0&limite=2&q=latin" || corpo["proxima"] != nil {
+		t.Errorf("links: anterior %v, próxima %v", corpo["anterior"], corpo["proxima"])
+	}
+	if status, _ := requisitar(t, novaAPIDeTeste(t), "GET", "/api/v1/busca?q=latin&limite=0"); status != http.StatusBadRequest {
+		t.Errorf("limite=0\nstatus esperado: 400; recebido: %d", status)
+	}
+}
+
+func TestFazRespondedor_paginação(t *testing.T) {
+	dados := &dadosWeb{linhas: carregar(strings.NewReader(linhas3Da43)), páginaMáxima: 2}
+	status, corpo := requisitarPágina(fazRespondedor(dados), "/?consulta=latin")
+	if status != http.StatusOK {
+		t.Fatalf("status: %d", status)
+	}
+	for _, trecho := range []string{"3 caractere(s) encontrado(s); exibindo 1 a 2", "U&#43;0041", "U&#43;0042",
+		`<a href="/?consulta=latin&amp;deslocamento=2&amp;limite=2" rel="next">`} {
+		if !strings.Contains(corpo, trecho) {
+			t.Errorf("página deveria conter %q:\n%s", trecho, corpo)
+		}
+	}
+	if strings.Contains(corpo, "U&#43;0043") || strings.Contains(corpo, `rel="prev"`) {
+		t.Errorf("a primeira página não deveria ter o terceiro resultado nem link para a anterior:\n%s", corpo)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// modelos das páginas HTML; html/template escapa nomes, consultas e
+// caracteres conforme o contexto em que aparecem
+var modelos = template.Must(template.New("páginas").Parse(`
+{{define "início"}}<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>sinais{{with .}}: {{.}}{{end}}</title>
+  <link rel="search" type="application/opensearchdescription+xml" title="sinais" href="/opensearch.xml">
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    td, th { padding: 0.2em 0.8em; text-align: left; }
+    .glifo { font-size: 4em; }
+  </style>
+</head>
+<body>
+  <form action="/" method="GET">
+    <input type="text" name="consulta" value="{{.}}" list="sugestoes" autocomplete="off" autofocus>
+    <datalist id="sugestoes"></datalist>
+    <input type="submit" value="Buscar">
+  </form>
+  <script src="/sugerir.js" defer></script>
+{{end}}
+
+{{define "fim"}}</body>
+</html>
+{{end}}
+
+{{define "busca"}}{{template "início" .Consulta}}
+{{- if .Consulta}}
+  <p>{{.Total}} caractere(s) encontrado(s){{if .Resultados}}; exibindo {{.Primeiro}} a {{.Último}}{{end}}</p>
+  {{- if .Resultados}}
+  <table>
+    <tr><th>código</th><th>caractere</th><th>nome</th></tr>
+    {{- range .Resultados}}
+    <tr><td><a href="/caractere/{{.Código}}">{{.Código}}</a></td><td>{{.Caractere}}</td><td>{{.Nome}}{{with .NomeAntigo}} ({{.}}){{end}}</td></tr>
+    {{- end}}
+  </table>
+  {{- end}}
+  <p>
+    {{- with .Anterior}}<a href="{{.}}" rel="prev">« anteriores</a>{{end}}
+    {{with .Próxima}}<a href="{{.}}" rel="next">próximos »</a>{{end -}}
+  </p>
+{{- end}}
+{{template "fim"}}{{end}}
+
+{{define "caractere"}}{{template "início" ""}}
+  <h1><span class="glifo">{{.Caractere}}</span> {{.Código}} {{.Nome}}</h1>
+  <table>
+    {{- with .Bloco}}
+    <tr><th>bloco</th><td>{{.}}</td></tr>
+    {{- end}}
+    {{- range .Propriedades}}
+    <tr><th>{{.Rótulo}}</th><td>{{.Valor}}</td></tr>
+    {{- end}}
+  </table>
+{{template "fim"}}{{end}}
+
+{{define "erro"}}{{template "início" ""}}
+  <p>{{.}}</p>
+{{template "fim"}}{{end}}
+`))
+
+// scriptSugestões preenche a lista da caixa de busca com /api/v1/sugerir
+// enquanto se digita; respostas de pedidos já superados são descartadas
+const scriptSugestões = `(function () {
+  var caixa = document.querySelector('input[name=consulta]');
+  var lista = document.getElementById('sugestoes');
+  var pedido = 0;
+  caixa.addEventListener('input', function () {
+    var texto = caixa.value, numero = ++pedido;
+    if (texto.trim() === '') {
+      lista.replaceChildren();
+      return;
+    }
+    fetch('/api/v1/sugerir?prefixo=' + encodeURIComponent(texto))
+      .then(function (r) { return r.ok ? r.json() : null; })
+      .then(function (resposta) {
+        if (!resposta || numero !== pedido) return;
+        var inicio = texto.replace(/[^ -]*$/, '');
+        var valores = resposta.palavras.map(function (p) { return inicio + p; })
+          .concat(resposta.nomes.map(function (n) { return n.nome; }));
+        lista.replaceChildren.apply(lista, valores.map(function (valor) {
+          var opcao = document.createElement('option');
+          opcao.value = valor;
+          return opcao;
+        }));
+      });
+  });
+})();
+`
+
+type páginaBusca struct {
+	Consulta string
+	paginação
+	Resultados []registro
+}
+
+// Primeiro e Último numeram a partir de 1 os resultados exibidos
+func (p páginaBusca) Primeiro() int { return p.Deslocamento + 1 }
+func (p páginaBusca) Último() int   { return p.Deslocamento + len(p.Resultados) }
+
+type páginaCaractere struct {
+	registro
+	Bloco        string
+	Propriedades []propriedade
+}
+
+func exibirPágina(w http.ResponseWriter, status int, modelo string, dados interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	modelos.ExecuteTemplate(w, modelo, dados)
+}
+
+// fazRespondedor devolve o tratador da página de busca, que mantém a
+// consulta na caixa de texto e lista uma página de resultados com links
+// para o detalhe de cada caractere
+func fazRespondedor(dados *dadosWeb) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			exibirPágina(w, http.StatusNotFound, "erro", "página não encontrada: "+r.URL.Path)
+			return
+		}
+		página := páginaBusca{Consulta: strings.TrimSpace(r.URL.Query().Get("consulta"))}
+		if página.Consulta != "" {
+			consulta := ExpandirSinônimos(strings.ToUpper(página.Consulta), dados.sinônimos)
+			índices, err := dados.filtrar(r.Context(), consulta)
+			if err != nil {
+				status, mensagem := erroDeConsulta(err)
+				exibirPágina(w, status, "erro", mensagem)
+				return
+			}
+			anotarResultados(w, len(índices))
+			p, err := paginar(r, len(índices), dados.páginaMáxima)
+			if err != nil {
+				exibirPágina(w, http.StatusBadRequest, "erro", err.Error())
+				return
+			}
+			página.paginação = p
+			início, fim := p.intervalo()
+			for _, c := range caracteresDe(dados.linhas, índices[início:fim]) {
+				página.Resultados = append(página.Resultados, novoRegistro(c))
+			}
+		}
+		exibirPágina(w, http.StatusOK, "busca", página)
+	}
+}
+
+// fazDetalhe devolve o tratador da página com todas as propriedades de um
+// caractere, em /caractere/U+2603
+func fazDetalhe(dados *dadosWeb) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runa, err := analisarCódigo(strings.TrimPrefix(r.URL.Path, "/caractere/"))
+		if err != nil {
+			exibirPágina(w, http.StatusBadRequest, "erro", err.Error())
+			return
+		}
+		c, ok := buscarCaractere(dados.linhas, runa)
+		if !ok {
+			exibirPágina(w, http.StatusNotFound, "erro", fmt.Sprintf("U+%04X não encontrado", runa))
+			return
+		}
+		exibirPágina(w, http.StatusOK, "caractere", páginaCaractere{novoRegistro(c), blocoDe(dados.blocos, runa), propriedades(c)})
+	}
+}
+
+func servirScriptSugestões(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	fmt.Fprint(w, scriptSugestões)
+}
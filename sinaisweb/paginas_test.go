@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// linhaMaliciosa tem um nome que seria interpretado como HTML sem escape
+const linhaMaliciosa = "E000;<script>alert(1)</script>;Co;0;L;;;;;N;;;;;"
+
+func requisitarPágina(tratador http.HandlerFunc, alvo string) (int, string) {
+	gravador := httptest.NewRecorder()
+	tratador(gravador, httptest.NewRequest("GET", alvo, nil))
+	return gravador.Code, gravador.Body.String()
+}
+
+func TestFazRespondedor(t *testing.T) {
+	linhas := append(carregar(strings.NewReader(linhas3Da43)), linhaMaliciosa)
+	tratador := fazRespondedor(&dadosWeb{linhas: linhas, páginaMáxima: páginaMáximaPadrão})
+	casos := []struct {
+		alvo      string
+		status    int
+		contém    []string
+		nãoContém []string
+	}{
+		{"/", http.StatusOK, []string{`name="consulta" value=""`}, []string{"<table>"}},
+		{"/?consulta=sign", http.StatusOK,
+			[]string{`value="sign"`, `<a href="/caractere/U&#43;003E">U&#43;003E</a>`, "<td>GREATER-THAN SIGN</td>", "<td>&gt;</td>", "2 caractere(s)"}, nil},
+		{"/?consulta=%3Cscript%3Ealert(1)%3C/script%3E", http.StatusOK,
+			[]string{"&lt;script&gt;alert(1)&lt;/script&gt;"}, []string{"<script>"}},
+		{`/?consulta="><b>`, http.StatusOK, []string{`value="&#34;&gt;&lt;b&gt;"`}, []string{"<b>"}},
+		{"/favicon.ico", http.StatusNotFound, nil, nil},
+	}
+	for _, caso := range casos {
+		status, corpo := requisitarPágina(tratador, caso.alvo)
+		if status != caso.status {
+			t.Errorf("GET %s\nstatus esperado: %d; recebido: %d", caso.alvo, caso.status, status)
+		}
+		for _, trecho := range caso.contém {
+			if !strings.Contains(corpo, trecho) {
+				t.Errorf("GET %s deveria conter %q:\n%s", caso.alvo, trecho, corpo)
+			}
+		}
+		for _, trecho := range caso.nãoContém {
+			if strings.Contains(corpo, trecho) {
+				t.Errorf("GET %s não deveria conter %q:\n%s", caso.alvo, trecho, corpo)
+			}
+		}
+	}
+}
+
+func TestFazDetalhe(t *testing.T) {
+	blocos := []bloco{{0, 0x7F, "Basic Latin"}}
+	tratador := fazDetalhe(&dadosWeb{linhas: carregar(strings.NewReader(linhas3Da43)), blocos: blocos})
+	status, corpo := requisitarPágina(tratador, "/caractere/U+0041")
+	if status != http.StatusOK {
+		t.Fatalf("status esperado: 200; recebido: %d", status)
+	}
+	for _, trecho := range []string{"LATIN CAPITAL LETTER A", "<th>bloco</th><td>Basic Latin</td>",
+		"<th>categoria</th><td>Lu</td>", "<th>minúscula</th><td>U&#43;0061 a</td>", "<th>UTF-8</th><td>41</td>"} {
+		if !strings.Contains(corpo, trecho) {
+			t.Errorf("detalhe deveria conter %q:\n%s", trecho, corpo)
+		}
+	}
+	if status, _ := requisitarPágina(tratador, "/caractere/2603"); status != http.StatusNotFound {
+		t.Errorf("caractere ausente\nstatus esperado: 404; recebido: %d", status)
+	}
+	if status, _ := requisitarPágina(tratador, "/caractere/xyz"); status != http.StatusBadRequest {
+		t.Errorf("código inválido\nstatus esperado: 400; recebido: %d", status)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// modos de exibição do progresso dos downloads
+const (
+	progressoAuto       = "auto"       // barra em terminais, linhas nos demais casos
+	progressoBarra      = "barra"      // uma linha reescrita no lugar
+	progressoLinhas     = "linhas"     // linhas esparsas, próprias para logs de CI
+	progressoSilencioso = "silencioso" // nada além dos erros
+)
+
+var modosProgresso = []string{progressoAuto, progressoBarra, progressoLinhas, progressoSilencioso}
+
+// andamento é atualizado pelo download e lido por quem exibe o progresso
+type andamento struct {
+	recebidos    atomic.Int64 // bytes do arquivo já gravados, incluindo os retomados
+	total        atomic.Int64 // tamanho do arquivo, ou -1 se desconhecido
+	transferidos atomic.Int64 // bytes recebidos nesta execução, para calcular a taxa
+}
+
+func novoAndamento() *andamento {
+	a := &andamento{}
+	a.total.Store(-1)
+	return a
+}
+
+// iniciar registra o início de uma tentativa a partir do byte início
+func (a *andamento) iniciar(início, total int64) {
+	a.recebidos.Store(início)
+	a.total.Store(total)
+}
+
+func (a *andamento) Write(p []byte) (int, error) {
+	a.recebidos.Add(int64(len(p)))
+	a.transferidos.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// formatarTamanho usa unidades binárias, como 1.5 MiB
+func formatarTamanho(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	valor := float64(n)
+	unidade := -1
+	for valor >= 1024 && unidade < 3 {
+		valor /= 1024
+		unidade++
+	}
+	return fmt.Sprintf("%.1f %ciB", valor, "KMGT"[unidade])
+}
+
+// exibidorProgresso escreve o andamento do download no modo escolhido
+type exibidorProgresso struct {
+	andamento *andamento
+	saída     io.Writer
+	modo      string
+	início    time.Time
+	últimaVez time.Time // da última linha, no modo linhas
+	últimoPct int64     // percentual da última linha, no modo linhas
+	desenhou  bool      // se há uma barra a terminar com nova linha
+}
+
+func novoExibidorProgresso(a *andamento, saída io.Writer, modo string) *exibidorProgresso {
+	if modo == "" || modo == progressoAuto {
+		modo = progressoLinhas
+		if arquivo, ok := saída.(*os.File); ok && éTerminal(int(arquivo.Fd())) {
+			modo = progressoBarra
+		}
+	}
+	agora := time.Now()
+	return &exibidorProgresso{andamento: a, saída: saída, modo: modo, início: agora, últimaVez: agora}
+}
+
+// descrever produz um texto como "1.2 MiB de 1.9 MiB (63%), 540.0 KiB/s"
+func (e *exibidorProgresso) descrever(agora time.Time) string {
+	recebidos, total := e.andamento.recebidos.Load(), e.andamento.total.Load()
+	texto := formatarTamanho(recebidos)
+	if total > 0 {
+		texto += fmt.Sprintf(" de %s (%d%%)", formatarTamanho(total), recebidos*100/total)
+	}
+	if segundos := agora.Sub(e.início).Seconds(); segundos > 0 {
+		taxa := float64(e.andamento.transferidos.Load()) / segundos
+		texto += fmt.Sprintf(", %s/s", formatarTamanho(int64(taxa)))
+	}
+	return texto
+}
+
+func (e *exibidorProgresso) atualizar(agora time.Time) {
+	switch e.modo {
+	case progressoBarra:
+		fmt.Fprintf(e.saída, "\r%s\x1b[K", e.descrever(agora))
+		e.desenhou = true
+	case progressoLinhas:
+		pct := int64(-1)
+		if total := e.andamento.total.Load(); total > 0 {
+			pct = e.andamento.recebidos.Load() * 100 / total
+		}
+		if pct >= e.últimoPct+10 || agora.Sub(e.últimaVez) >= 5*time.Second {
+			fmt.Fprintln(e.saída, e.descrever(agora))
+			e.últimaVez, e.últimoPct = agora, pct/10*10
+		}
+	}
+}
+
+func (e *exibidorProgresso) concluir(err error, agora time.Time) {
+	if e.modo == progressoSilencioso {
+		return
+	}
+	if e.desenhou {
+		fmt.Fprintln(e.saída)
+	}
+	if err == nil {
+		duração := agora.Sub(e.início).Round(100 * time.Millisecond)
+		fmt.Fprintf(e.saída, "concluído: %s em %v\n", formatarTamanho(e.andamento.recebidos.Load()), duração)
+	}
+}
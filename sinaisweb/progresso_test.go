@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFormatarTamanho(t *testing.T) {
+	casos := []struct {
+		bytes    int64
+		esperado string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1887405, "1.8 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, caso := range casos {
+		if obtido := formatarTamanho(caso.bytes); obtido != caso.esperado {
+			t.Errorf("formatarTamanho(%d)\nesperado: %q; recebido: %q", caso.bytes, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestExibidorProgresso(t *testing.T) {
+	início := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	casos := []struct {
+		modo     string
+		total    int64
+		esperado string
+	}{
+		{progressoBarra, 2048, "\r1.0 KiB de 2.0 KiB (50%), 512 B/s\x1b[K\r2.0 KiB de 2.0 KiB (100%), 512 B/s\x1b[K\n" +
+			"concluído: 2.0 KiB em 4s\n"},
+		{progressoLinhas, 2048, "1.0 KiB de 2.0 KiB (50%), 512 B/s\n2.0 KiB de 2.0 KiB (100%), 512 B/s\n" +
+			"concluído: 2.0 KiB em 4s\n"},
+		{progressoLinhas, -1, "concluído: 2.0 KiB em 4s\n"},
+		{progressoSilencioso, 2048, ""},
+	}
+	for _, caso := range casos {
+		var saída bytes.Buffer
+		a := novoAndamento()
+		a.iniciar(0, caso.total)
+		exibidor := novoExibidorProgresso(a, &saída, caso.modo)
+		exibidor.início, exibidor.últimaVez = início, início
+		a.Write(make([]byte, 1024))
+		exibidor.atualizar(início.Add(2 * time.Second))
+		a.Write(make([]byte, 1024))
+		exibidor.atualizar(início.Add(4 * time.Second))
+		exibidor.concluir(nil, início.Add(4*time.Second))
+		if saída.String() != caso.esperado {
+			t.Errorf("modo %s, total %d\nesperado: %q\nrecebido: %q", caso.modo, caso.total, caso.esperado, saída.String())
+		}
+	}
+}
+
+func TestNovoExibidorProgresso_auto(t *testing.T) {
+	if modo := novoExibidorProgresso(novoAndamento(), &bytes.Buffer{}, progressoAuto).modo; modo != progressoLinhas {
+		t.Errorf("fora de um terminal, esperado: %q; recebido: %q", progressoLinhas, modo)
+	}
+}
+
+func TestBaixarAtomicamente_andamento(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(linhas3Da43))
+	}))
+	defer srv.Close()
+	opções := opçõesDownload{andamento: novoAndamento()}
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	if err := baixarAtomicamente(context.Background(), srv.URL, caminho, opções); err != nil {
+		t.Fatal(err)
+	}
+	esperado := int64(len(linhas3Da43))
+	if r, tot := opções.andamento.recebidos.Load(), opções.andamento.total.Load(); r != esperado || tot != esperado {
+		t.Errorf("andamento esperado: %d de %d; recebido: %d de %d", esperado, esperado, r, tot)
+	}
+}
+
+func TestBaixarAtomicamente_cancelado(t *testing.T) {
+	ctx, cancelar := context.WithCancel(context.Background())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(len(linhas3Da43)))
+		w.Write([]byte(linhas3Da43[:10]))
+		w.(http.Flusher).Flush()
+		cancelar()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	caminho := filepath.Join(t.TempDir(), "UnicodeData.txt")
+	err := baixarAtomicamente(ctx, srv.URL, caminho, opçõesDeTeste())
+	if !errors.Is(err, ErrDownloadCancelado) {
+		t.Errorf("erro esperado: %v; recebido: %v", ErrDownloadCancelado, err)
+	}
+	for _, resto := range []string{caminho, caminho + ".parcial"} {
+		if _, err := os.Stat(resto); !os.IsNotExist(err) {
+			t.Errorf("%s não deveria existir após o cancelamento", filepath.Base(resto))
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	taxaPadrão           = 0.0 // requisições por segundo por cliente; desativado
+	rajadaPadrão         = 20
+	consultaMáximaPadrão = 200 // caracteres em cada parâmetro
+	orçamentoPadrão      = 2 * time.Second
+	intervaloLimpeza     = time.Minute
+)
+
+// opçõesServidor reúne os limites contra abuso; valores zero desativam
+// cada proteção. O limite de taxa vem desativado porque, atrás de um
+// balanceador e sem ProxiesConfiáveis, todos os clientes teriam o IP do
+// balanceador e dividiriam o mesmo balde.
+type opçõesServidor struct {
+	Taxa              float64 // fichas repostas por segundo em cada balde
+	Rajada            int     // capacidade de cada balde
+	ProxiesConfiáveis []netip.Prefix
+	ConsultaMáxima    int
+	Orçamento         time.Duration // tempo máximo de cada consulta
+	URLPública        string        // endereço público do serviço, sem / no fim
+}
+
+// analisarRedes aceita endereços IP e redes em notação CIDR
+func analisarRedes(itens []string) ([]netip.Prefix, error) {
+	redes := []netip.Prefix{}
+	for _, item := range itens {
+		if rede, err := netip.ParsePrefix(item); err == nil {
+			redes = append(redes, rede.Masked())
+			continue
+		}
+		endereço, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("endereço ou rede inválido: %q", item)
+		}
+		redes = append(redes, netip.PrefixFrom(endereço, endereço.BitLen()))
+	}
+	return redes, nil
+}
+
+func (o opçõesServidor) confiável(ip string) bool {
+	endereço, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	endereço = endereço.Unmap()
+	for _, rede := range o.ProxiesConfiáveis {
+		if rede.Contains(endereço) {
+			return true
+		}
+	}
+	return false
+}
+
+// endereçoCliente devolve o IP de quem abriu a conexão
+func endereçoCliente(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// clienteDe devolve o IP do cliente. O X-Forwarded-For só é considerado
+// quando a conexão vem de um proxy confiável, e é lido da direita para a
+// esquerda até o primeiro endereço que não seja de outro proxy confiável,
+// já que os da esquerda podem ter sido forjados pelo cliente.
+func (o opçõesServidor) clienteDe(r *http.Request) string {
+	ip := endereçoCliente(r)
+	if !o.confiável(ip) {
+		return ip
+	}
+	saltos := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(saltos) - 1; i >= 0; i-- {
+		salto := strings.TrimSpace(saltos[i])
+		if salto == "" {
+			continue
+		}
+		if !o.confiável(salto) {
+			return salto
+		}
+		ip = salto
+	}
+	return ip
+}
+
+// balde é o balde de fichas de um cliente
+type balde struct {
+	fichas float64
+	última time.Time
+}
+
+// limitador aplica um balde de fichas por cliente: cada requisição gasta
+// uma ficha, e as fichas são repostas à taxa até a capacidade da rajada
+type limitador struct {
+	mu            sync.Mutex
+	taxa          float64
+	rajada        float64
+	baldes        map[string]*balde
+	agora         func() time.Time
+	últimaLimpeza time.Time
+}
+
+// novoLimitador devolve nil, que permite tudo, se a taxa é 0
+func novoLimitador(taxa float64, rajada int) *limitador {
+	if taxa <= 0 {
+		return nil
+	}
+	if rajada < 1 {
+		rajada = 1
+	}
+	return &limitador{taxa: taxa, rajada: float64(rajada), baldes: map[string]*balde{}, agora: time.Now}
+}
+
+// permitir gasta uma ficha do cliente; se não houver, devolve quanto
+// tempo falta para a próxima
+func (l *limitador) permitir(cliente string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	agora := l.agora()
+	l.limpar(agora)
+	b, ok := l.baldes[cliente]
+	if !ok {
+		b = &balde{fichas: l.rajada, última: agora}
+		l.baldes[cliente] = b
+	}
+	b.fichas = math.Min(l.rajada, b.fichas+agora.Sub(b.última).Seconds()*l.taxa)
+	b.última = agora
+	if b.fichas >= 1 {
+		b.fichas--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.fichas) / l.taxa * float64(time.Second))
+}
+
+// limpar descarta os baldes que já estariam cheios, que se comportam
+// como baldes novos, para que a memória não cresça com cada IP visto
+func (l *limitador) limpar(agora time.Time) {
+	if agora.Sub(l.últimaLimpeza) < intervaloLimpeza {
+		return
+	}
+	l.últimaLimpeza = agora
+	for cliente, b := range l.baldes {
+		if b.fichas+agora.Sub(b.última).Seconds()*l.taxa >= l.rajada {
+			delete(l.baldes, cliente)
+		}
+	}
+}
+
+// recusar responde em JSON na API e em texto nas demais rotas
+func recusar(w http.ResponseWriter, r *http.Request, status int, formato string, args ...interface{}) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		responderErro(w, status, formato, args...)
+		return
+	}
+	http.Error(w, fmt.Sprintf(formato, args...), status)
+}
+
+// segundosAté arredonda para cima, como pede o Retry-After
+func segundosAté(espera time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(espera.Seconds()))))
+}
+
+// proteger aplica o limite de taxa e o tamanho máximo dos parâmetros;
+// devolve false se a requisição já foi recusada
+func (s *serviço) proteger(w http.ResponseWriter, r *http.Request) bool {
+	if ok, espera := s.limitador.permitir(s.opções.clienteDe(r)); !ok {
+		w.Header().Set("Retry-After", segundosAté(espera))
+		recusar(w, r, http.StatusTooManyRequests, "muitas requisições; tente de novo em %s", espera.Round(time.Millisecond))
+		return false
+	}
+	if máximo := s.opções.ConsultaMáxima; máximo > 0 {
+		for nome, valores := range r.URL.Query() {
+			for _, valor := range valores {
+				if utf8.RuneCountInString(valor) > máximo {
+					recusar(w, r, http.StatusBadRequest, "parâmetro %s maior que %d caracteres", nome, máximo)
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// erroDeConsulta traduz as falhas de uma consulta, como o fim do seu
+// orçamento de tempo, em status e mensagem
+func erroDeConsulta(err error) (int, string) {
+	if errors.Is(err, errConsultaVazia) {
+		return http.StatusBadRequest, err.Error()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusServiceUnavailable, "a consulta excedeu o tempo máximo; refine os termos"
+	}
+	return http.StatusServiceUnavailable, err.Error()
+}
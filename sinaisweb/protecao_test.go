@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalisarRedes(t *testing.T) {
+	redes, err := analisarRedes([]string{"10.0.0.0/8", "192.168.1.7", "::1", "172.16.5.4/12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := []string{"10.0.0.0/8", "192.168.1.7/32", "::1/128", "172.16.0.0/12"}
+	for i, rede := range redes {
+		if rede.String() != esperado[i] {
+			t.Errorf("rede %d\nesperado: %s; recebido: %s", i, esperado[i], rede)
+		}
+	}
+	for _, inválido := range []string{"10.0.0.0/33", "intranet", "1.2.3"} {
+		if _, err := analisarRedes([]string{inválido}); err == nil {
+			t.Errorf("analisarRedes(%q) deveria devolver erro", inválido)
+		}
+	}
+}
+
+func TestClienteDe(t *testing.T) {
+	confiáveis, _ := analisarRedes([]string{"10.0.0.0/8"})
+	opções := opçõesServidor{ProxiesConfiáveis: confiáveis}
+	casos := []struct {
+		remoto    string
+		encaminho string
+		esperado  string
+	}{
+		{"198.51.100.9:4000", "", "198.51.100.9"},
+		{"198.51.100.9:4000", "203.0.113.5", "198.51.100.9"}, // cliente direto não escolhe seu IP
+		{"10.0.0.1:4000", "203.0.113.5", "203.0.113.5"},
+		{"10.0.0.1:4000", "203.0.113.5, 10.0.0.2", "203.0.113.5"},
+		{"10.0.0.1:4000", "6.6.6.6, 203.0.113.5, 10.0.0.2", "203.0.113.5"}, // o da esquerda pode ser forjado
+		{"10.0.0.1:4000", "10.0.0.3, 10.0.0.2", "10.0.0.3"},
+		{"10.0.0.1:4000", "", "10.0.0.1"},
+		{"[::ffff:10.0.0.1]:4000", "203.0.113.5", "203.0.113.5"},
+	}
+	for _, caso := range casos {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = caso.remoto
+		if caso.encaminho != "" {
+			r.Header.Set("X-Forwarded-For", caso.encaminho)
+		}
+		if obtido := opções.clienteDe(r); obtido != caso.esperado {
+			t.Errorf("clienteDe(%s, X-Forwarded-For: %q)\nesperado: %s; recebido: %s",
+				caso.remoto, caso.encaminho, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestLimitador(t *testing.T) {
+	agora := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := novoLimitador(2, 3)
+	l.agora = func() time.Time { return agora }
+	passos := []struct {
+		avanço  time.Duration
+		cliente string
+		permite bool
+		espera  time.Duration
+	}{
+		{0, "a", true, 0},
+		{0, "a", true, 0},
+		{0, "a", true, 0},
+		{0, "a", false, 500 * time.Millisecond},
+		{0, "b", true, 0}, // cada cliente tem seu balde
+		{250 * time.Millisecond, "a", false, 250 * time.Millisecond},
+		{250 * time.Millisecond, "a", true, 0},
+		{0, "a", false, 500 * time.Millisecond},
+		{10 * time.Second, "a", true, 0}, // o balde não passa da rajada
+		{0, "a", true, 0},
+		{0, "a", true, 0},
+		{0, "a", false, 500 * time.Millisecond},
+	}
+	for i, passo := range passos {
+		agora = agora.Add(passo.avanço)
+		permite, espera := l.permitir(passo.cliente)
+		if permite != passo.permite || espera != passo.espera {
+			t.Errorf("passo %d (%s)\nesperado: %v, %v; recebido: %v, %v", i, passo.cliente, passo.permite, passo.espera, permite, espera)
+		}
+	}
+	agora = agora.Add(2 * intervaloLimpeza)
+	l.permitir("c")
+	if len(l.baldes) != 1 {
+		t.Errorf("baldes cheios deveriam ser descartados; restam %d", len(l.baldes))
+	}
+	if permite, _ := novoLimitador(0, 0).permitir("a"); !permite {
+		t.Errorf("limitador com taxa 0 deveria permitir tudo")
+	}
+}
+
+func TestServiço_proteções(t *testing.T) {
+	s := novoServiço(opçõesServidor{Taxa: 1, Rajada: 2, ConsultaMáxima: 10})
+	s.carregar(novosDadosDeTeste(), "15.1.0")
+	requisitar := func(alvo string) *httptest.ResponseRecorder {
+		gravador := httptest.NewRecorder()
+		s.ServeHTTP(gravador, httptest.NewRequest("GET", alvo, nil))
+		return gravador
+	}
+	if status := requisitar("/api/v1/busca?q=" + strings.Repeat("a", 11)).Code; status != http.StatusBadRequest {
+		t.Errorf("parâmetro longo demais\nesperado: 400; recebido: %d", status)
+	}
+	requisitar("/api/v1/busca?q=sign")
+	gravador := requisitar("/api/v1/busca?q=sign")
+	if gravador.Code != http.StatusTooManyRequests || gravador.Header().Get("Retry-After") != "1" {
+		t.Errorf("limite de taxa\nesperado: 429 com Retry-After 1; recebido: %d %v", gravador.Code, gravador.Header())
+	}
+	if !strings.Contains(gravador.Body.String(), `"status":429`) {
+		t.Errorf("a API deveria recusar em JSON: %s", gravador.Body.String())
+	}
+	if status := requisitar("/?consulta=sign").Code; status != http.StatusTooManyRequests {
+		t.Errorf("páginas também são limitadas\nesperado: 429; recebido: %d", status)
+	}
+	for _, sonda := range []string{"/healthz", "/readyz", "/metrics"} {
+		if status := requisitar(sonda).Code; status != http.StatusOK {
+			t.Errorf("%s não deveria ser limitada; status %d", sonda, status)
+		}
+	}
+
+	casos := []struct{ alvo, tipo, corpo string }{
+		{"/api/v1/busca", "application/json", `{"status":413,"erro":"corpo da requisição maior que 4 bytes"}`},
+		{"/", "text/plain", "corpo da requisição maior que 4 bytes"},
+	}
+	for _, caso := range casos {
+		gravador = httptest.NewRecorder()
+		limitarCorpo(s, 4).ServeHTTP(gravador, httptest.NewRequest("POST", caso.alvo, strings.NewReader("12345")))
+		tipo, corpo := gravador.Header().Get("Content-Type"), strings.TrimSpace(gravador.Body.String())
+		if gravador.Code != http.StatusRequestEntityTooLarge || !strings.HasPrefix(tipo, caso.tipo) || corpo != caso.corpo {
+			t.Errorf("POST %s com corpo grande demais\nesperado: 413 %s %s; recebido: %d %s %s",
+				caso.alvo, caso.tipo, caso.corpo, gravador.Code, tipo, corpo)
+		}
+	}
+}
+
+func TestServiço_orçamento(t *testing.T) {
+	s := novoServiço(opçõesServidor{Orçamento: time.Nanosecond})
+	dados := novosDadosDeTeste()
+	dados.consultas = novoCacheConsultas(10)
+	s.carregar(dados, "15.1.0")
+	for _, alvo := range []string{"/api/v1/busca?q=sign", "/?consulta=sign"} {
+		gravador := httptest.NewRecorder()
+		s.ServeHTTP(gravador, httptest.NewRequest("GET", alvo, nil))
+		if gravador.Code != http.StatusServiceUnavailable {
+			t.Errorf("GET %s além do orçamento\nesperado: 503; recebido: %d", alvo, gravador.Code)
+		}
+	}
+	if consultas, _ := dados.consultas.tamanho(); consultas != 0 {
+		t.Errorf("consultas interrompidas não deveriam ficar no cache")
+	}
+}
+
+func TestFiltrarÍndices(t *testing.T) {
+	linhas := carregar(strings.NewReader(linhas3Da43))
+	ctx, cancelar := context.WithCancel(context.Background())
+	if resultado, err := filtrarÍndices(ctx, linhas, "SIGN"); err != nil || len(resultado) != 2 {
+		t.Errorf("filtrarÍndices: %v, %v", resultado, err)
+	}
+	cancelar()
+	if _, err := filtrarÍndices(ctx, linhas, "SIGN"); err != context.Canceled {
+		t.Errorf("filtrarÍndices com contexto cancelado\nesperado: %v; recebido: %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// níveisLog traduz os níveis aceitos nas chaves log-nivel
+var níveisLog = map[string]slog.Level{
+	"depuracao": slog.LevelDebug,
+	"info":      slog.LevelInfo,
+	"aviso":     slog.LevelWarn,
+	"erro":      slog.LevelError,
+}
+
+var formatosLog = []string{"texto", "json"}
+
+func nomesNíveisLog() string {
+	nomes := make([]string, 0, len(níveisLog))
+	for nome := range níveisLog {
+		nomes = append(nomes, nome)
+	}
+	sort.Slice(nomes, func(i, j int) bool { return níveisLog[nomes[i]] < níveisLog[nomes[j]] })
+	return strings.Join(nomes, ", ")
+}
+
+// novoRegistrador cria o logger no nível e formato escolhidos; nível e
+// formato já foram validados pela configuração
+func novoRegistrador(saída io.Writer, nível, formato string) *slog.Logger {
+	opções := &slog.HandlerOptions{Level: níveisLog[nível]}
+	if formato == "json" {
+		return slog.New(slog.NewJSONHandler(saída, opções))
+	}
+	return slog.New(slog.NewTextHandler(saída, opções))
+}
+
+// usarRegistrador torna padrão o logger descrito na configuração, para
+// que diagnósticos da linha de comando e do servidor saiam no mesmo formato
+func usarRegistrador(cfg *configuração) {
+	slog.SetDefault(novoRegistrador(os.Stderr, cfg.NívelLog, cfg.FormatoLog))
+}
+
+// registrarAcesso escreve uma linha por requisição; as sondas e /metrics
+// ficam no nível de depuração para não encobrir o tráfego real
+func registrarAcesso(registro *slog.Logger, r *http.Request, cliente, rota string, g *gravadorResposta, duração time.Duration) {
+	nível := slog.LevelInfo
+	if rota == "/healthz" || rota == "/readyz" || rota == "/metrics" {
+		nível = slog.LevelDebug
+	}
+	atributos := []slog.Attr{
+		slog.String("metodo", r.Method),
+		slog.String("caminho", r.URL.Path),
+		slog.String("consulta", r.URL.RawQuery),
+		slog.String("rota", rota),
+		slog.Int("status", g.status),
+		slog.Float64("duracao_ms", float64(duração.Microseconds())/1000),
+		slog.String("ip", cliente),
+	}
+	if g.resultados >= 0 {
+		atributos = append(atributos, slog.Int("resultados", g.resultados))
+	}
+	if encaminhado := r.Header.Get("X-Forwarded-For"); encaminhado != "" {
+		atributos = append(atributos, slog.String("x_forwarded_for", encaminhado))
+	}
+	registro.LogAttrs(r.Context(), nível, "requisição", atributos...)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNovoRegistrador(t *testing.T) {
+	casos := []struct {
+		nível, formato string
+		contém         []string
+		nãoContém      []string
+	}{
+		{"info", "texto", []string{"level=INFO msg=informação", "level=WARN msg=aviso"}, []string{"depuração"}},
+		{"aviso", "texto", []string{"level=WARN msg=aviso"}, []string{"informação"}},
+		{"depuracao", "json", []string{`"level":"DEBUG","msg":"depuração"`, `"chave":"valor"`}, nil},
+	}
+	for _, caso := range casos {
+		var saída bytes.Buffer
+		registro := novoRegistrador(&saída, caso.nível, caso.formato)
+		registro.Debug("depuração", "chave", "valor")
+		registro.Info("informação", "chave", "valor")
+		registro.Warn("aviso", "chave", "valor")
+		for _, trecho := range caso.contém {
+			if !strings.Contains(saída.String(), trecho) {
+				t.Errorf("%s/%s deveria conter %q:\n%s", caso.nível, caso.formato, trecho, saída.String())
+			}
+		}
+		for _, trecho := range caso.nãoContém {
+			if strings.Contains(saída.String(), trecho) {
+				t.Errorf("%s/%s não deveria conter %q:\n%s", caso.nível, caso.formato, trecho, saída.String())
+			}
+		}
+	}
+}
+
+func TestServiço_registroDeAcesso(t *testing.T) {
+	var saída bytes.Buffer
+	s := novoServiço(opçõesServidor{})
+	s.registro = novoRegistrador(&saída, "info", "json")
+	s.carregar(novosDadosDeTeste(), "15.1.0")
+	requisição := httptest.NewRequest("GET", "/api/v1/busca?q=sign", nil)
+	requisição.RemoteAddr = "192.0.2.7:51234"
+	requisição.Header.Set("X-Forwarded-For", "198.51.100.1")
+	s.ServeHTTP(httptest.NewRecorder(), requisição)
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+
+	linhas := strings.Split(strings.TrimSpace(saída.String()), "\n")
+	if len(linhas) != 1 {
+		t.Fatalf("esperada uma linha de acesso; /healthz só aparece em depuração:\n%s", saída.String())
+	}
+	registro := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(linhas[0]), &registro); err != nil {
+		t.Fatal(err)
+	}
+	esperado := map[string]interface{}{
+		"msg": "requisição", "metodo": "GET", "caminho": "/api/v1/busca", "consulta": "q=sign",
+		"rota": "/api/v1/busca", "status": float64(200), "resultados": float64(2), "ip": "192.0.2.7",
+		"x_forwarded_for": "198.51.100.1",
+	}
+	for chave, valor := range esperado {
+		if registro[chave] != valor {
+			t.Errorf("%s\nesperado: %v; recebido: %v", chave, valor, registro[chave])
+		}
+	}
+	if _, ok := registro["duracao_ms"].(float64); !ok {
+		t.Errorf("registro sem duracao_ms: %v", registro)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	promptInterativo = "sinais> "
+	máximoHistórico  = 1000
+)
+
+// sessão guarda os dados carregados e as preferências do modo interativo
+type sessão struct {
+	linhas    []string
+	formato   string
+	sinônimos map[string]string
+	saída     io.Writer
+}
+
+// metaComando é um comando do modo interativo iniciado por ':'
+type metaComando struct {
+	nome     string
+	uso      string
+	resumo   string
+	executar func(s *sessão, args []string) error
+}
+
+var metaComandos []metaComando
+
+// errSair encerra o modo interativo
+var errSair = errors.New("sair")
+
+func init() {
+	metaComandos = []metaComando{
+		{"formato", "[NOME]", "exibe ou troca o formato de saída (" + nomesFormatos() + ")", metaFormato},
+		{"info", "CÓDIGO...", "exibe as propriedades de caracteres", metaInfo},
+		{"descrever", "TEXTO", "exibe o código e o nome de cada caractere do texto", metaDescrever},
+		{"ajuda", "", "exibe esta lista de comandos", metaAjuda},
+		{"sair", "", "encerra o modo interativo (ou Ctrl-D)", func(*sessão, []string) error { return errSair }},
+	}
+}
+
+func metaFormato(s *sessão, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(s.saída, s.formato)
+		return nil
+	}
+	if _, ok := formatadores[args[0]]; !ok {
+		return fmt.Errorf("formato desconhecido: %q (use: %s)", args[0], nomesFormatos())
+	}
+	s.formato = args[0]
+	return nil
+}
+
+func metaInfo(s *sessão, args []string) error {
+	if len(args) == 0 {
+		return errors.New("uso: :info CÓDIGO...")
+	}
+	return exibirInfo(s.saída, s.linhas, args, s.formato)
+}
+
+func metaDescrever(s *sessão, args []string) error {
+	fmt.Fprint(s.saída, Descrever(s.linhas, strings.Join(args, " ")))
+	return nil
+}
+
+func metaAjuda(s *sessão, args []string) error {
+	fmt.Fprintln(s.saída, "Digite palavras para buscar caracteres pelo nome, ou:")
+	for _, meta := range metaComandos {
+		fmt.Fprintf(s.saída, "  %-22s %s\n", ":"+strings.TrimSpace(meta.nome+" "+meta.uso), meta.resumo)
+	}
+	return nil
+}
+
+// executarLinha trata uma linha digitada: meta-comando ou consulta
+func (s *sessão) executarLinha(linha string) error {
+	linha = strings.TrimSpace(linha)
+	if linha == "" {
+		return nil
+	}
+	if strings.HasPrefix(linha, ":") {
+		partes := strings.Fields(linha[1:])
+		if len(partes) == 0 {
+			return metaAjuda(s, nil)
+		}
+		for _, meta := range metaComandos {
+			if meta.nome == partes[0] {
+				return meta.executar(s, partes[1:])
+			}
+		}
+		return fmt.Errorf("comando desconhecido: %q (digite :ajuda)", ":"+partes[0])
+	}
+	consulta := ExpandirSinônimos(strings.ToUpper(linha), s.sinônimos)
+	return Formatar(s.saída, Filtrar(s.linhas, consulta), s.formato)
+}
+
+// caminhoHistórico segue a especificação XDG para arquivos de estado
+func caminhoHistórico() (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(base, "sinais", "historico"), nil
+}
+
+func carregarHistórico(caminho string) []string {
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return nil
+	}
+	defer arquivo.Close()
+	return carregar(arquivo)
+}
+
+func salvarHistórico(caminho string, histórico []string) error {
+	if len(histórico) > máximoHistórico {
+		histórico = histórico[len(histórico)-máximoHistórico:]
+	}
+	if err := os.MkdirAll(filepath.Dir(caminho), 0o755); err != nil {
+		return err
+	}
+	conteúdo := strings.Join(histórico, "\n") + "\n"
+	return os.WriteFile(caminho, []byte(conteúdo), 0o600)
+}
+
+// Interagir lê consultas e meta-comandos da entrada até o fim dos dados
+// ou até o comando :sair. Se a entrada é um terminal, as linhas podem ser
+// editadas e o histórico é preservado entre sessões.
+func Interagir(s *sessão, entrada *os.File) error {
+	if !éTerminal(int(entrada.Fd())) {
+		varredor := bufio.NewScanner(entrada)
+		for varredor.Scan() {
+			err := s.executarLinha(varredor.Text())
+			if err == errSair {
+				return nil
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		return varredor.Err()
+	}
+
+	caminho, err := caminhoHistórico()
+	if err != nil {
+		return err
+	}
+	editor := novoEditorLinha(entrada, s.saída, carregarHistórico(caminho))
+	defer func() {
+		if err := salvarHistórico(caminho, editor.histórico); err != nil {
+			fmt.Fprintln(os.Stderr, "erro ao salvar histórico:", err)
+		}
+	}()
+	fmt.Fprintln(s.saída, "sinais: modo interativo. Digite :ajuda para ver os comandos.")
+	for {
+		restaurar, err := modoBruto(int(entrada.Fd()))
+		if err != nil {
+			return err
+		}
+		linha, err := editor.LerLinha(promptInterativo)
+		restaurar()
+		if err == io.EOF {
+			return nil
+		} else if err == ErrInterrompido {
+			continue
+		} else if err != nil {
+			return err
+		}
+		editor.adicionarAoHistórico(linha)
+		err = s.executarLinha(linha)
+		if err == errSair {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
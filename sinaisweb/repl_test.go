@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Example_modoInterativo() {
+	s := &sessão{linhas: carregar(strings.NewReader(linhas3Da43)), formato: "texto", saída: os.Stdout}
+	for _, linha := range []string{"sign", ":formato json", ":info @", ":formato", ":sair", "mark"} {
+		if err := s.executarLinha(linha); err != nil {
+			break
+		}
+	}
+	// Output:
+	// U+003D	=	EQUALS SIGN
+	// U+003E	>	GREATER-THAN SIGN
+	// [
+	//   {
+	//     "codigo": "U+0040",
+	//     "caractere": "@",
+	//     "nome": "COMMERCIAL AT",
+	//     "categoria": "Po",
+	//     "combinacao": "0",
+	//     "bidi": "ON"
+	//   }
+	// ]
+	// json
+}
+
+func TestExecutarLinha_erros(t *testing.T) {
+	s := &sessão{linhas: carregar(strings.NewReader(linhas3Da43)), formato: "texto", saída: os.Stdout}
+	for _, linha := range []string{":nada", ":formato xml", ":info"} {
+		if err := s.executarLinha(linha); err == nil {
+			t.Errorf("executarLinha(%q) deveria devolver erro", linha)
+		}
+	}
+	if s.formato != "texto" {
+		t.Errorf("formato inválido não deveria ser aceito: %q", s.formato)
+	}
+}
+
+func TestHistórico(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "sinais", "historico")
+	histórico := []string{"cat", "snowman"}
+	if err := salvarHistórico(caminho, histórico); err != nil {
+		t.Fatal(err)
+	}
+	if obtido := carregarHistórico(caminho); !reflect.DeepEqual(obtido, histórico) {
+		t.Errorf("carregarHistórico\nesperado: %q; recebido: %q", histórico, obtido)
+	}
+}
+
+func TestCaminhoHistórico(t *testing.T) {
+	caminhoAntes, existia := os.LookupEnv("XDG_STATE_HOME")
+	defer restaurar("XDG_STATE_HOME", caminhoAntes, existia)
+	os.Setenv("XDG_STATE_HOME", "/tmp/estado")
+	obtido, _ := caminhoHistórico()
+	if esperado := "/tmp/estado/sinais/historico"; obtido != esperado {
+		t.Errorf("caminhoHistórico()\nesperado: %q; recebido: %q", esperado, obtido)
+	}
+}
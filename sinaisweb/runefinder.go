@@ -3,24 +3,18 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
+	"io/fs"
+	"log/slog"
 	"os"
-	"os/user"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// URLUCD fica em http://www.unicode.org/Public/UNIDATA/UnicodeData.txt
-// mas unicode.org não é confiável, então esta URL alternativa pode ser usada:
-// http://turing.com.br/etc/UnicodeData.txt
-const URLUCD = "http://turing.com.br/etc/UnicodeData.txt"
-
-const ENDEREÇO = ":8080"
-
 // AnalisarLinha devolve a runa, o nome e uma fatia de palavras que
 // ocorrem no campo nome de uma linha do UnicodeData.txt
 func AnalisarLinha(linha string) (rune, string, []string) {
@@ -77,20 +71,44 @@ func carregar(texto io.Reader) []string {
 	return linhas
 }
 
-// Listar produz texto com listagem com código, runa e nome dos
-// caracteres Unicode cujo nome contem as palavras da consulta.
-func Listar(linhas []string, consulta string) string {
-	termos := separar(consulta)
-
-	var buffer bytes.Buffer
+// Filtrar devolve os caracteres Unicode cujo nome contem as palavras da consulta.
+func Filtrar(linhas []string, consulta string) []Caractere {
+	índices, _ := filtrarÍndices(context.Background(), linhas, consulta)
+	return caracteresDe(linhas, índices)
+}
 
-	for _, linha := range linhas {
-		runa, nome, palavrasNome := AnalisarLinha(linha) // ➊
-		if contémTodos(palavrasNome, termos) {           // ➋
-			buffer.WriteString(fmt.Sprintf("U+%04X\t%[1]c\t%s\n", runa, nome))
+// filtrarÍndices devolve as posições em linhas dos caracteres encontrados
+// e desiste quando o contexto termina, para que uma consulta do servidor
+// não consuma mais que seu orçamento de tempo
+func filtrarÍndices(ctx context.Context, linhas []string, consulta string) ([]int32, error) {
+	termos := separar(consulta)
+	resultado := []int32{}
+	for i, linha := range linhas {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		_, _, palavrasNome := AnalisarLinha(linha)
+		if contémTodos(palavrasNome, termos) {
+			resultado = append(resultado, int32(i))
+		}
+	}
+	return resultado, nil
+}
+
+// caracteresDe analisa as linhas indicadas pelos índices
+func caracteresDe(linhas []string, índices []int32) []Caractere {
+	caracteres := make([]Caractere, len(índices))
+	for i, índice := range índices {
+		caracteres[i] = AnalisarCaractere(linhas[índice])
 	}
+	return caracteres
+}
 
+// Listar produz texto com listagem com código, runa e nome dos
+// caracteres Unicode cujo nome contem as palavras da consulta.
+func Listar(linhas []string, consulta string) string {
+	var buffer bytes.Buffer
+	formatarTexto(&buffer, Filtrar(linhas, consulta))
 	return buffer.String()
 }
 
@@ -101,54 +119,54 @@ func Exibir(linhas []string, consulta string) {
 }
 
 func obterCaminhoUCD() string {
-	caminhoUCD := os.Getenv("UCD_PATH")
-	if caminhoUCD == "" { // ➊
-		usuário, err := user.Current()                    // ➋
-		terminarSe(err)                                   // ➌
-		caminhoUCD = usuário.HomeDir + "/UnicodeData.txt" // ➍
-	}
-	return caminhoUCD
+	cfg, err := carregarConfiguração()
+	terminarSe(err)
+	return cfg.caminhoUCD()
 }
 
 func terminarSe(err error) {
 	if err != nil {
-		log.Fatal(err.Error())
+		slog.Error(err.Error())
+		os.Exit(1)
 	}
 }
 
-func baixarUCD(url, caminho string, feito chan<- bool) { // ➊
-	resposta, err := http.Get(url)
-	terminarSe(err)
-	defer resposta.Body.Close()
-	arquivo, err := os.Create(caminho)
-	terminarSe(err)
-	defer arquivo.Close()
-	_, err = io.Copy(arquivo, resposta.Body)
-	terminarSe(err)
-	feito <- true // ➋
+// baixarUCD grava o conteúdo da url em um arquivo temporário, e só o
+// renomeia para o caminho final depois de validar status, conteúdo e,
+// se informada, a soma SHA-256. O resultado é enviado pelo canal feito.
+func baixarUCD(ctx context.Context, url, caminho string, opções opçõesDownload, feito chan<- error) { // ➊
+	feito <- baixarAtomicamente(ctx, url, caminho, opções) // ➋
 }
 
-func progresso(feito <-chan bool) { // ➊
+// progresso exibe o andamento do download na saída até que o resultado
+// chegue pelo canal feito
+func progresso(feito <-chan error, a *andamento, saída io.Writer, modo string) error { // ➊
+	exibidor := novoExibidorProgresso(a, saída, modo)
+	relógio := time.NewTicker(150 * time.Millisecond)
+	defer relógio.Stop()
 	for { // ➋
 		select { // ➌
-		case <-feito: // ➍
-			fmt.Println()
-			return
-		default: // ➎
-			fmt.Print(".")
-			time.Sleep(150 * time.Millisecond)
+		case err := <-feito: // ➍
+			exibidor.concluir(err, time.Now())
+			return err
+		case agora := <-relógio.C: // ➎
+			exibidor.atualizar(agora)
 		}
 	}
 }
 
 func abrirUCD(caminho string) (*os.File, error) {
+	return abrirOuBaixar(caminho, relativoUCD, opçõesDownload{})
+}
+
+// abrirOuBaixar abre o arquivo, baixando antes dos espelhos se ele não existir
+func abrirOuBaixar(caminho, relativo string, opções opçõesDownload) (*os.File, error) {
 	ucd, err := os.Open(caminho)
 	if os.IsNotExist(err) { // ➊
-		fmt.Printf("%s não encontrado\nbaixando %s\n", caminho, URLUCD)
-		feito := make(chan bool)             // ➊
-		go baixarUCD(URLUCD, caminho, feito) // ➋
-		progresso(feito)                     // ➌
-		ucd, err = os.Open(caminho)          // ➌
+		if _, err := baixarDeEspelhos(relativo, caminho, opções); err != nil { // ➋
+			return nil, err
+		}
+		ucd, err = os.Open(caminho) // ➌
 	}
 	return ucd, err // ➍
 }
@@ -157,6 +175,9 @@ func extrairOpções(args []string) (opções []string, resto []string) {
 	opções = []string{}
 	resto = []string{}
 	for _, item := range args {
+		if item == "" {
+			continue
+		}
 		if item[0] == '-' {
 			opções = append(opções, item)
 		} else {
@@ -166,48 +187,71 @@ func extrairOpções(args []string) (opções []string, resto []string) {
 	return opções, resto
 }
 
-const html = `<html><head/>
-<body>
-   <form action="/" method="GET">
-   <input type="text" name="consulta">
-   <input type="submit" value="Buscar">
-  </form>
-  <pre>%s</pre>
-</body></html>`
-
-func fazRespondedor(linhas []string) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		saida := ""
-		if r.URL.Query().Encode() != "" {
-			consulta := r.URL.Query().Get("consulta")
-			if consulta != "" {
-				consulta = strings.ToUpper(consulta)
-				saida = Listar(linhas, consulta)
-			}
-		}
-		fmt.Fprintf(w, html, saida)
+// abrirUCDLocal abre o UnicodeData.txt sem baixar nada: do caminho em
+// UCD_PATH, que pode ser um UCD.zip, ou do diretório de dados
+func abrirUCDLocal(cfg *configuração) (io.ReadCloser, error) {
+	caminho := cfg.caminhoExplícito()
+	if caminho == "" {
+		return cfg.repositório().abrirLocal("UnicodeData.txt")
+	}
+	if strings.HasSuffix(caminho, ".zip") {
+		return abrirDoZip(caminho, "UnicodeData.txt")
 	}
+	return os.Open(caminho)
 }
 
-// IniciarServidor sobe um servidor HTTP para receber consultas
-func IniciarServidor(linhas []string, consulta string) {
-	http.HandleFunc("/", fazRespondedor(linhas))
-	fmt.Println("Servindo HTTP em", ENDEREÇO)
-	http.ListenAndServe(ENDEREÇO, nil)
+// carregarUCD abre o UnicodeData.txt, baixando se preciso, e carrega suas
+// linhas seguidas das linhas dos arquivos extras da configuração
+func carregarUCD(cfg *configuração) ([]string, error) {
+	ucd, err := abrirUCDLocal(cfg)
+	caminho := cfg.caminhoExplícito()
+	if errors.Is(err, fs.ErrNotExist) && caminho == "" {
+		ucd, err = cfg.repositório().abrir("UnicodeData.txt")
+	} else if errors.Is(err, fs.ErrNotExist) && !strings.HasSuffix(caminho, ".zip") {
+		ucd, err = abrirOuBaixar(caminho, relativoUCD, cfg.opçõesDownload())
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer ucd.Close()
+	linhas := carregar(ucd)
+	for _, caminho := range cfg.Extras {
+		extras, err := carregarExtras(caminho)
+		if err != nil {
+			return nil, err
+		}
+		linhas = append(linhas, extras...)
+	}
+	return linhas, nil
 }
 
-func main() {
-	opções, palavras := extrairOpções(os.Args[1:])
-	consulta := strings.Join(palavras, " ")
-	consulta = strings.ToUpper(consulta)
-	ucd, err := abrirUCD(obterCaminhoUCD()) // ➊
+// carregarExtras lê um arquivo extra no formato do UnicodeData.txt,
+// ignorando linhas em branco e comentários iniciados por #, e recusa
+// linhas com menos de 15 campos, que não poderiam ser analisadas
+func carregarExtras(caminho string) ([]string, error) {
+	arquivo, err := os.Open(caminho)
 	if err != nil {
-		log.Fatal(err.Error())
+		return nil, err
 	}
-	defer ucd.Close()
-	if contém(opções, "-w") {
-		IniciarServidor(carregar(ucd), consulta)
-	} else {
-		Exibir(carregar(ucd), consulta)
+	defer arquivo.Close()
+	linhas := []string{}
+	varredor := bufio.NewScanner(arquivo)
+	for número := 1; varredor.Scan(); número++ {
+		linha := strings.TrimSpace(varredor.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+		if campos := strings.Count(linha, ";") + 1; campos < 15 {
+			return nil, fmt.Errorf("%s:%d: esperados 15 campos separados por ';', encontrados %d", caminho, número, campos)
+		}
+		linhas = append(linhas, linha)
+	}
+	return linhas, varredor.Err()
+}
+
+func main() {
+	if cfg, err := carregarConfiguração(); err == nil {
+		usarRegistrador(cfg)
 	}
+	terminarSe(Executar(os.Args[1:]))
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -223,9 +224,11 @@ func TestBaixarUCD(t *testing.T) {
 	defer srv.Close()
 
 	caminhoUCD := fmt.Sprintf("./TEST%d-UnicodeData.txt", time.Now().UnixNano())
-	feito := make(chan bool)                 // ➊
-	go baixarUCD(srv.URL, caminhoUCD, feito) // ➋
-	_ = <-feito                              // ➌
+	feito := make(chan error)                                                        // ➊
+	go baixarUCD(context.Background(), srv.URL, caminhoUCD, opçõesDownload{}, feito) // ➋
+	if err := <-feito; err != nil {                                                  // ➌
+		t.Errorf("baixarUCD(%q): %v", srv.URL, err)
+	}
 	ucd, err := os.Open(caminhoUCD)
 	if os.IsNotExist(err) {
 		t.Errorf("baixarUCD não gerou:%v\n%v", caminhoUCD, err)
@@ -256,6 +259,7 @@ func TestExtrairOpções(t *testing.T) {
 		{[]string{"A", "B"}, []string{}, []string{"A", "B"}},
 		{[]string{"A", "-x", "B"}, []string{"-x"}, []string{"A", "B"}},
 		{[]string{"-?"}, []string{"-?"}, []string{}},
+		{[]string{"", "A", ""}, []string{}, []string{"A"}},
 	}
 	for _, caso := range casos {
 		opções, resto := extrairOpções(caso.args)
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	endereçoPadrão = ":8080"
+
+	tempoLeituraCabeçalho = 5 * time.Second
+	tempoLeitura          = 10 * time.Second
+	tempoEscrita          = 30 * time.Second
+	tempoOcioso           = 2 * time.Minute
+	tempoEncerramento     = 10 * time.Second // espera pelas requisições em andamento
+
+	tamanhoMáximoCabeçalho = 16 << 10
+	tamanhoMáximoCorpo     = 64 << 10
+)
+
+// novoRoteador registra as páginas HTML e a API em um ServeMux próprio,
+// sem tocar no http.DefaultServeMux
+func novoRoteador(dados *dadosWeb, opções opçõesServidor) *http.ServeMux {
+	rotas := http.NewServeMux()
+	rotas.Handle("/", dados.cacheável(fazRespondedor(dados)))
+	rotas.Handle("/caractere/", dados.cacheável(fazDetalhe(dados)))
+	rotas.Handle("/sugerir.js", dados.cacheável(servirScriptSugestões))
+	rotas.HandleFunc("/opensearch.xml", fazOpenSearch(opções))
+	rotas.Handle("/api/", novaAPI(dados))
+	return rotas
+}
+
+// limitarCorpo recusa requisições com corpo declarado acima do limite e
+// impede que corpos sem tamanho declarado sejam lidos além dele
+func limitarCorpo(próximo http.Handler, limite int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limite {
+			recusar(w, r, http.StatusRequestEntityTooLarge, "corpo da requisição maior que %d bytes", limite)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limite)
+		próximo.ServeHTTP(w, r)
+	})
+}
+
+// serviço responde às sondas e às métricas desde o início e às páginas e à
+// API depois que os dados forem carregados
+type serviço struct {
+	métricas  *métricas
+	registro  *slog.Logger
+	opções    opçõesServidor
+	limitador *limitador                    // nil sem limite de taxa
+	aplicação atomic.Pointer[http.ServeMux] // nil enquanto os dados carregam
+}
+
+func novoServiço(opções opçõesServidor) *serviço {
+	return &serviço{métricas: novasMétricas(), registro: slog.Default(),
+		opções: opções, limitador: novoLimitador(opções.Taxa, opções.Rajada)}
+}
+
+// carregar publica os dados e torna o serviço pronto
+func (s *serviço) carregar(dados *dadosWeb, versão string) {
+	dados.identidade = identificarDados(versão, dados)
+	s.aplicação.Store(novoRoteador(dados, s.opções))
+	s.métricas.definirDados(versão, len(dados.linhas))
+}
+
+func (s *serviço) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	início := time.Now()
+	gravador := &gravadorResposta{ResponseWriter: w, resultados: -1}
+	rota := s.atender(gravador, r)
+	if gravador.status == 0 {
+		gravador.status = http.StatusOK
+	}
+	duração := time.Since(início)
+	s.métricas.observarRequisição(rota, gravador.status, duração)
+	if gravador.resultados >= 0 {
+		s.métricas.observarResultados(rota, gravador.resultados)
+	}
+	registrarAcesso(s.registro, r, s.opções.clienteDe(r), rota, gravador, duração)
+}
+
+// atender despacha a requisição e devolve a rota usada como rótulo nas
+// métricas: o padrão registrado, não o caminho, para limitar as séries
+func (s *serviço) atender(w http.ResponseWriter, r *http.Request) string {
+	switch r.URL.Path {
+	case "/healthz":
+		io.WriteString(w, "ok\n")
+		return r.URL.Path
+	case "/readyz":
+		if s.aplicação.Load() == nil {
+			http.Error(w, "carregando dados", http.StatusServiceUnavailable)
+		} else {
+			io.WriteString(w, "ok\n")
+		}
+		return r.URL.Path
+	case "/metrics":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.métricas.escrever(w)
+		return r.URL.Path
+	}
+	rotas := s.aplicação.Load()
+	if rotas == nil {
+		w.Header().Set("Retry-After", "5")
+		recusar(w, r, http.StatusServiceUnavailable, "carregando dados")
+		return "indisponível"
+	}
+	tratador, padrão := rotas.Handler(r)
+	if a, ok := tratador.(*api); ok {
+		_, padrão = a.rotas.Handler(r)
+	}
+	if !s.proteger(w, r) {
+		return padrão
+	}
+	if s.opções.Orçamento > 0 {
+		ctx, cancelar := context.WithTimeout(r.Context(), s.opções.Orçamento)
+		defer cancelar()
+		r = r.WithContext(ctx)
+	}
+	tratador.ServeHTTP(w, r)
+	return padrão
+}
+
+// novoServidor configura prazos e limites para que clientes lentos ou
+// abusivos não retenham conexões indefinidamente
+func novoServidor(s *serviço) *http.Server {
+	return &http.Server{
+		Handler:           limitarCorpo(comprimir(s), tamanhoMáximoCorpo),
+		ReadHeaderTimeout: tempoLeituraCabeçalho,
+		ReadTimeout:       tempoLeitura,
+		WriteTimeout:      tempoEscrita,
+		IdleTimeout:       tempoOcioso,
+		MaxHeaderBytes:    tamanhoMáximoCabeçalho,
+	}
+}
+
+// servir atende as conexões do ouvinte até que o contexto seja cancelado;
+// então para de aceitar conexões e espera as requisições em andamento
+func servir(ctx context.Context, servidor *http.Server, ouvinte net.Listener) error {
+	erros := make(chan error, 1)
+	go func() {
+		erros <- servidor.Serve(ouvinte)
+	}()
+	select {
+	case err := <-erros:
+		return err
+	case <-ctx.Done():
+	}
+	encerrar, cancelar := context.WithTimeout(context.Background(), tempoEncerramento)
+	defer cancelar()
+	if err := servidor.Shutdown(encerrar); err != nil {
+		return fmt.Errorf("encerrando o servidor: %w", err)
+	}
+	if err := <-erros; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// IniciarServidor sobe um servidor HTTP para receber consultas pelas
+// páginas HTML e pela API JSON em /api/v1/, até receber SIGINT ou SIGTERM.
+// Os dados são carregados com o servidor já no ar, e /readyz indica
+// quando terminam; se a carga falhar, o servidor é encerrado com o erro.
+func IniciarServidor(endereço string, opções opçõesServidor, carregar func() (*dadosWeb, string, error)) error {
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
+	ouvinte, err := net.Listen("tcp", endereço)
+	if err != nil {
+		return err
+	}
+	slog.Info("servindo HTTP", "endereco", ouvinte.Addr().String())
+	s := novoServiço(opções)
+	ctx, cancelar := context.WithCancel(ctx)
+	defer cancelar()
+	falhaNaCarga := make(chan error, 1)
+	go func() {
+		dados, versão, err := carregar()
+		if err != nil {
+			falhaNaCarga <- err
+			cancelar()
+			return
+		}
+		s.carregar(dados, versão)
+		slog.Info("dados carregados", "caracteres", len(dados.linhas), "versao", versão)
+	}()
+	if err := servir(ctx, novoServidor(s), ouvinte); err != nil {
+		return err
+	}
+	select {
+	case err := <-falhaNaCarga:
+		return err
+	default:
+	}
+	slog.Info("servidor encerrado")
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func novosDadosDeTeste() *dadosWeb {
+	linhas := carregar(strings.NewReader(linhas3Da43))
+	return &dadosWeb{linhas: linhas, páginaMáxima: páginaMáximaPadrão, sugestões: novoSugestor(linhas)}
+}
+
+func TestNovoServidor_rotas(t *testing.T) {
+	s := novoServiço(opçõesServidor{})
+	s.carregar(novosDadosDeTeste(), "15.1.0")
+	servidor := novoServidor(s)
+	casos := []struct {
+		método string
+		alvo   string
+		corpo  string
+		status int
+		tipo   string
+	}{
+		{"GET", "/?consulta=sign", "", http.StatusOK, "text/html"},
+		{"GET", "/caractere/U+0041", "", http.StatusOK, "text/html"},
+		{"GET", "/sugerir.js", "", http.StatusOK, "text/javascript"},
+		{"GET", "/api/v1/busca?q=sign", "", http.StatusOK, "application/json"},
+		{"GET", "/api/v1/sugerir?prefixo=si", "", http.StatusOK, "application/json"},
+		{"POST", "/api/v1/busca", strings.Repeat("x", tamanhoMáximoCorpo+1), http.StatusRequestEntityTooLarge, "application/json"},
+		{"POST", "/api/v1/busca", "x", http.StatusMethodNotAllowed, "application/json"},
+	}
+	for _, caso := range casos {
+		gravador := httptest.NewRecorder()
+		servidor.Handler.ServeHTTP(gravador, httptest.NewRequest(caso.método, caso.alvo, strings.NewReader(caso.corpo)))
+		if gravador.Code != caso.status || !strings.HasPrefix(gravador.Header().Get("Content-Type"), caso.tipo) {
+			t.Errorf("%s %s\nesperado: %d %s; recebido: %d %s", caso.método, caso.alvo,
+				caso.status, caso.tipo, gravador.Code, gravador.Header().Get("Content-Type"))
+		}
+	}
+	if servidor.ReadHeaderTimeout == 0 || servidor.ReadTimeout == 0 || servidor.WriteTimeout == 0 || servidor.IdleTimeout == 0 {
+		t.Errorf("servidor sem prazos: %+v", servidor)
+	}
+	if _, padrão := http.DefaultServeMux.Handler(httptest.NewRequest("GET", "/", nil)); padrão != "" {
+		t.Errorf("rota registrada no DefaultServeMux: %q", padrão)
+	}
+}
+
+func TestServir_encerramentoGracioso(t *testing.T) {
+	ouvinte, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	começou := make(chan bool)
+	servidor := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/lenta" {
+			começou <- true
+			time.Sleep(200 * time.Millisecond)
+		}
+		io.WriteString(w, "ok")
+	})}
+	ctx, cancelar := context.WithCancel(context.Background())
+	terminou := make(chan error)
+	go func() { terminou <- servir(ctx, servidor, ouvinte) }()
+
+	url := "http://" + ouvinte.Addr().String()
+	if resposta, err := http.Get(url + "/"); err != nil || resposta.StatusCode != http.StatusOK {
+		t.Fatalf("GET /: %v %v", resposta, err)
+	}
+	respostaLenta := make(chan error)
+	go func() {
+		resposta, err := http.Get(url + "/lenta")
+		if err == nil {
+			corpo, _ := io.ReadAll(resposta.Body)
+			resposta.Body.Close()
+			if string(corpo) != "ok" {
+				t.Errorf("requisição em andamento\nesperado: %q; recebido: %q", "ok", corpo)
+			}
+		}
+		respostaLenta <- err
+	}()
+	<-começou
+	cancelar()
+	if err := <-respostaLenta; err != nil {
+		t.Errorf("requisição em andamento interrompida pelo encerramento: %v", err)
+	}
+	if err := <-terminou; err != nil {
+		t.Errorf("servir: %v", err)
+	}
+	if _, err := net.Dial("tcp", ouvinte.Addr().String()); err == nil {
+		t.Errorf("servidor ainda aceita conexões depois de encerrado")
+	}
+}
+
+func TestIniciarServidor_endereçoInválido(t *testing.T) {
+	carregar := func() (*dadosWeb, string, error) { return novosDadosDeTeste(), "", nil }
+	if err := IniciarServidor("endereço inválido", opçõesServidor{}, carregar); err == nil {
+		t.Errorf("IniciarServidor deveria devolver o erro de net.Listen")
+	}
+}
+
+func TestIniciarServidor_falhaNaCarga(t *testing.T) {
+	falha := errors.New("UnicodeData.txt ausente")
+	carregar := func() (*dadosWeb, string, error) { return nil, "", falha }
+	if err := IniciarServidor("127.0.0.1:0", opçõesServidor{}, carregar); err != falha {
+		t.Errorf("IniciarServidor\nesperado: %v; recebido: %v", falha, err)
+	}
+}
+
+func TestServiço_prontidão(t *testing.T) {
+	s := novoServiço(opçõesServidor{})
+	casos := []struct {
+		alvo          string
+		antes, depois int
+	}{
+		{"/healthz", http.StatusOK, http.StatusOK},
+		{"/readyz", http.StatusServiceUnavailable, http.StatusOK},
+		{"/api/v1/busca?q=sign", http.StatusServiceUnavailable, http.StatusOK},
+		{"/?consulta=sign", http.StatusServiceUnavailable, http.StatusOK},
+		{"/metrics", http.StatusOK, http.StatusOK},
+	}
+	requisitar := func(alvo string) int {
+		gravador := httptest.NewRecorder()
+		s.ServeHTTP(gravador, httptest.NewRequest("GET", alvo, nil))
+		return gravador.Code
+	}
+	for _, caso := range casos {
+		if status := requisitar(caso.alvo); status != caso.antes {
+			t.Errorf("GET %s antes da carga\nesperado: %d; recebido: %d", caso.alvo, caso.antes, status)
+		}
+	}
+	s.carregar(novosDadosDeTeste(), "15.1.0")
+	for _, caso := range casos {
+		if status := requisitar(caso.alvo); status != caso.depois {
+			t.Errorf("GET %s depois da carga\nesperado: %d; recebido: %d", caso.alvo, caso.depois, status)
+		}
+	}
+}
+
+func TestServiço_apiAntesDaCarga(t *testing.T) {
+	casos := []struct {
+		alvo, tipo, corpo string
+	}{
+		{"/api/v1/busca?q=sign", "application/json", `{"status":503,"erro":"carregando dados"}`},
+		{"/?consulta=sign", "text/plain", "carregando dados"},
+	}
+	for _, caso := range casos {
+		gravador := httptest.NewRecorder()
+		novoServiço(opçõesServidor{}).ServeHTTP(gravador, httptest.NewRequest("GET", caso.alvo, nil))
+		tipo, corpo := gravador.Header().Get("Content-Type"), strings.TrimSpace(gravador.Body.String())
+		if gravador.Code != http.StatusServiceUnavailable || gravador.Header().Get("Retry-After") != "5" {
+			t.Errorf("GET %s antes da carga\nesperado: 503 com Retry-After 5; recebido: %d %v", caso.alvo, gravador.Code, gravador.Header())
+		}
+		if !strings.HasPrefix(tipo, caso.tipo) || corpo != caso.corpo {
+			t.Errorf("GET %s antes da carga\nesperado: %s %s; recebido: %s %s", caso.alvo, caso.tipo, caso.corpo, tipo, corpo)
+		}
+	}
+}
+
+func TestServiço_métricas(t *testing.T) {
+	s := novoServiço(opçõesServidor{})
+	s.carregar(novosDadosDeTeste(), "15.1.0")
+	for _, alvo := range []string{"/api/v1/busca?q=sign", "/api/v1/busca?q=latin", "/api/v1/caractere/U+0041",
+		"/api/v1/caractere/U+0042", "/api/v1/caractere/xyz", "/?consulta=mark", "/favicon.ico"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", alvo, nil))
+	}
+	gravador := httptest.NewRecorder()
+	s.ServeHTTP(gravador, httptest.NewRequest("GET", "/metrics", nil))
+	corpo := gravador.Body.String()
+	for _, linha := range []string{
+		`sinais_requisicoes_total{rota="/api/v1/busca",status="200"} 2`,
+		`sinais_requisicoes_total{rota="/api/v1/caractere/",status="200"} 2`,
+		`sinais_requisicoes_total{rota="/api/v1/caractere/",status="400"} 1`,
+		`sinais_requisicoes_total{rota="/",status="200"} 1`,
+		`sinais_requisicoes_total{rota="/",status="404"} 1`,
+		`sinais_duracao_requisicao_segundos_count{rota="/api/v1/caractere/"} 3`,
+		`sinais_resultados_bucket{rota="/api/v1/busca",le="1"} 0`,
+		`sinais_resultados_bucket{rota="/api/v1/busca",le="5"} 2`,
+		`sinais_resultados_sum{rota="/api/v1/busca"} 5`,
+		`sinais_resultados_count{rota="/"} 1`,
+		`sinais_dados_info{versao="15.1.0"} 1`,
+		`sinais_caracteres 7`,
+		`sinais_pronto 1`,
+	} {
+		if !strings.Contains(corpo, linha+"\n") {
+			t.Errorf("/metrics deveria conter %q:\n%s", linha, corpo)
+		}
+	}
+	if tipo := gravador.Header().Get("Content-Type"); !strings.HasPrefix(tipo, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type de /metrics: %q", tipo)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	sugestõesPadrão    = 10 // sugestões de cada tipo quando o cliente não informa
+	máximoSugestões    = 20 // sugestões guardadas em cada nó do índice
+	profundidadeÍndice = 8  // prefixos mais longos são buscados na lista ordenada
+)
+
+// termo é uma palavra do vocabulário ou o nome completo de um caractere
+type termo struct {
+	texto string
+	peso  int  // ocorrências da palavra nos nomes; maior é mais provável
+	runa  rune // só nos nomes
+}
+
+// precede ordena os termos do mais ao menos provável: maior peso, depois
+// texto mais curto, depois ordem alfabética
+func precede(a, b termo) bool {
+	if a.peso != b.peso {
+		return a.peso > b.peso
+	}
+	if len(a.texto) != len(b.texto) {
+		return len(a.texto) < len(b.texto)
+	}
+	return a.texto < b.texto
+}
+
+type arestaPrefixo struct {
+	byte byte
+	nó   *nóPrefixo
+}
+
+// nóPrefixo guarda os melhores termos que começam com o prefixo do nó,
+// já ordenados, para que a consulta não precise percorrer a subárvore
+type nóPrefixo struct {
+	filhos   []arestaPrefixo // em ordem de byte
+	melhores []int32         // índices em índicePrefixos.termos
+}
+
+func (n *nóPrefixo) filho(b byte) *nóPrefixo {
+	i := sort.Search(len(n.filhos), func(i int) bool { return n.filhos[i].byte >= b })
+	if i < len(n.filhos) && n.filhos[i].byte == b {
+		return n.filhos[i].nó
+	}
+	return nil
+}
+
+// oferecer inclui o termo i entre os melhores do nó, se ele couber
+func (n *nóPrefixo) oferecer(termos []termo, i int) {
+	pos := sort.Search(len(n.melhores), func(j int) bool { return precede(termos[i], termos[n.melhores[j]]) })
+	if pos >= máximoSugestões {
+		return
+	}
+	if len(n.melhores) < máximoSugestões {
+		n.melhores = append(n.melhores, 0)
+	}
+	copy(n.melhores[pos+1:], n.melhores[pos:])
+	n.melhores[pos] = int32(i)
+}
+
+// índicePrefixos é uma árvore de prefixos sobre os bytes dos termos,
+// construída uma vez e consultada sem alocação de nós
+type índicePrefixos struct {
+	termos []termo // em ordem alfabética
+	raiz   *nóPrefixo
+}
+
+func novoÍndicePrefixos(termos []termo) *índicePrefixos {
+	sort.Slice(termos, func(i, j int) bool { return termos[i].texto < termos[j].texto })
+	índice := &índicePrefixos{termos: termos, raiz: &nóPrefixo{}}
+	for i, t := range termos {
+		nó := índice.raiz
+		nó.oferecer(termos, i)
+		for p := 0; p < len(t.texto) && p < profundidadeÍndice; p++ {
+			próximo := nó.filho(t.texto[p])
+			if próximo == nil {
+				// os termos chegam em ordem, então o novo filho é sempre o último
+				próximo = &nóPrefixo{}
+				nó.filhos = append(nó.filhos, arestaPrefixo{t.texto[p], próximo})
+			}
+			nó = próximo
+			nó.oferecer(termos, i)
+		}
+	}
+	return índice
+}
+
+// buscar devolve até n termos que começam com o prefixo, do mais provável
+// ao menos provável
+func (índice *índicePrefixos) buscar(prefixo string, n int) []termo {
+	var nó *nóPrefixo
+	if len(prefixo) <= profundidadeÍndice {
+		nó = índice.raiz
+		for i := 0; i < len(prefixo) && nó != nil; i++ {
+			nó = nó.filho(prefixo[i])
+		}
+		if nó == nil {
+			return nil
+		}
+	} else {
+		// abaixo da profundidade do índice restam poucos termos por prefixo
+		nó = &nóPrefixo{}
+		i := sort.Search(len(índice.termos), func(i int) bool { return índice.termos[i].texto >= prefixo })
+		for ; i < len(índice.termos) && strings.HasPrefix(índice.termos[i].texto, prefixo); i++ {
+			nó.oferecer(índice.termos, i)
+		}
+	}
+	if n > len(nó.melhores) {
+		n = len(nó.melhores)
+	}
+	resultado := make([]termo, n)
+	for i := range resultado {
+		resultado[i] = índice.termos[nó.melhores[i]]
+	}
+	return resultado
+}
+
+// sugestor completa consultas com palavras do vocabulário e nomes de
+// caracteres
+type sugestor struct {
+	palavras *índicePrefixos
+	nomes    *índicePrefixos
+}
+
+func novoSugestor(linhas []string) *sugestor {
+	ocorrências := map[string]int{}
+	nomes := []termo{}
+	for _, linha := range linhas {
+		_, _, palavras := AnalisarLinha(linha)
+		for _, palavra := range palavras {
+			ocorrências[palavra]++
+		}
+		c := AnalisarCaractere(linha)
+		if !strings.HasPrefix(c.Nome, "<") { // <control>, <CJK Ideograph, First> etc.
+			nomes = append(nomes, termo{texto: c.Nome, runa: c.Runa})
+		}
+	}
+	palavras := make([]termo, 0, len(ocorrências))
+	for palavra, n := range ocorrências {
+		palavras = append(palavras, termo{texto: palavra, peso: n})
+	}
+	return &sugestor{palavras: novoÍndicePrefixos(palavras), nomes: novoÍndicePrefixos(nomes)}
+}
+
+// sugerir completa a última palavra da consulta e o nome inteiro de
+// caracteres que começam com ela
+func (s *sugestor) sugerir(consulta string, n int) (palavras []string, nomes []Caractere) {
+	consulta = strings.TrimLeft(strings.ToUpper(consulta), " ")
+	palavras, nomes = []string{}, []Caractere{}
+	if termos := separar(consulta); len(termos) > 0 && !strings.HasSuffix(consulta, " ") && !strings.HasSuffix(consulta, "-") {
+		for _, t := range s.palavras.buscar(termos[len(termos)-1], n) {
+			palavras = append(palavras, t.texto)
+		}
+	}
+	if consulta != "" {
+		for _, t := range s.nomes.buscar(consulta, n) {
+			nomes = append(nomes, Caractere{Runa: t.runa, Nome: t.texto})
+		}
+	}
+	return palavras, nomes
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func textos(termos []termo) []string {
+	resultado := []string{}
+	for _, t := range termos {
+		resultado = append(resultado, t.texto)
+	}
+	return resultado
+}
+
+func TestÍndicePrefixos_buscar(t *testing.T) {
+	índice := novoÍndicePrefixos([]termo{
+		{texto: "SMILE", peso: 1},
+		{texto: "SMILING", peso: 5},
+		{texto: "SMALL", peso: 9},
+		{texto: "SMILINGLY", peso: 5},
+		{texto: "SMILINGNESS", peso: 2},
+		{texto: "SMILINGNESSES", peso: 7},
+		{texto: "SUN", peso: 3},
+	})
+	casos := []struct {
+		prefixo  string
+		n        int
+		esperado []string
+	}{
+		{"S", 3, []string{"SMALL", "SMILINGNESSES", "SMILING"}},
+		{"SMI", 10, []string{"SMILINGNESSES", "SMILING", "SMILINGLY", "SMILINGNESS", "SMILE"}},
+		{"SMILE", 10, []string{"SMILE"}},
+		// além da profundidade do índice
+		{"SMILINGNE", 10, []string{"SMILINGNESSES", "SMILINGNESS"}},
+		{"SMILINGNESSES", 10, []string{"SMILINGNESSES"}},
+		{"SMILINGNESSESX", 10, []string{}},
+		{"X", 10, []string{}},
+		{"", 2, []string{"SMALL", "SMILINGNESSES"}},
+	}
+	for _, caso := range casos {
+		obtido := textos(índice.buscar(caso.prefixo, caso.n))
+		if !reflect.DeepEqual(obtido, caso.esperado) {
+			t.Errorf("buscar(%q, %d)\nesperado: %q; recebido: %q", caso.prefixo, caso.n, caso.esperado, obtido)
+		}
+	}
+}
+
+func TestÍndicePrefixos_limiteDoNó(t *testing.T) {
+	termos := []termo{}
+	for i := 0; i < máximoSugestões+5; i++ {
+		termos = append(termos, termo{texto: "A" + strings.Repeat("B", i), peso: i})
+	}
+	obtido := novoÍndicePrefixos(termos).buscar("A", 100)
+	if len(obtido) != máximoSugestões || obtido[0].peso != máximoSugestões+4 {
+		t.Errorf("buscar(\"A\", 100): %d termos, primeiro com peso %d", len(obtido), obtido[0].peso)
+	}
+}
+
+func TestSugestor_sugerir(t *testing.T) {
+	s := novoSugestor(append(carregar(strings.NewReader(linhas3Da43)), "0000;<control>;Cc;0;BN;;;;;N;NULL;;;;"))
+	casos := []struct {
+		consulta string
+		palavras []string
+		nomes    []string
+	}{
+		{"s", []string{"SIGN"}, []string{}},
+		{"latin capital l", []string{"LATIN", "LETTER"}, []string{"LATIN CAPITAL LETTER A", "LATIN CAPITAL LETTER B", "LATIN CAPITAL LETTER C"}},
+		{"greater-t", []string{"THAN"}, []string{"GREATER-THAN SIGN"}},
+		{"latin ", []string{}, []string{"LATIN CAPITAL LETTER A", "LATIN CAPITAL LETTER B", "LATIN CAPITAL LETTER C"}},
+		{"co", []string{"COMMERCIAL"}, []string{"COMMERCIAL AT"}},
+		{"<", []string{"<control>"}, []string{}},
+		{"nu", []string{"NULL"}, []string{}},
+	}
+	for _, caso := range casos {
+		palavras, caracteres := s.sugerir(caso.consulta, 10)
+		nomes := []string{}
+		for _, c := range caracteres {
+			nomes = append(nomes, c.Nome)
+		}
+		if !reflect.DeepEqual(palavras, caso.palavras) || !reflect.DeepEqual(nomes, caso.nomes) {
+			t.Errorf("sugerir(%q)\nesperado: %q, %q; recebido: %q, %q", caso.consulta, caso.palavras, caso.nomes, palavras, nomes)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package main
+
+import "syscall"
+
+const (
+	ioctlLerTermios    = syscall.TIOCGETA
+	ioctlGravarTermios = syscall.TIOCSETA
+)
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+func éTerminal(fd int) bool {
+	return false
+}
+
+func modoBruto(fd int) (func(), error) {
+	return nil, errors.New("modo bruto não suportado neste sistema")
+}
+
+func tamanhoTerminal(fd int) (int, int, error) {
+	return 0, 0, errors.New("tamanho do terminal não disponível neste sistema")
+}
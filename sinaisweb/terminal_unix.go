@@ -0,0 +1,63 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func lerTermios(fd int) (syscall.Termios, error) {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlLerTermios, uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return t, errno
+	}
+	return t, nil
+}
+
+func gravarTermios(fd int, t syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlGravarTermios, uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// éTerminal informa se o descritor de arquivo é um terminal
+func éTerminal(fd int) bool {
+	_, err := lerTermios(fd)
+	return err == nil
+}
+
+// modoBruto desliga eco e edição de linha do terminal, e devolve uma
+// função que restaura o modo anterior
+func modoBruto(fd int) (func(), error) {
+	original, err := lerTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	bruto := original
+	bruto.Iflag &^= syscall.ICRNL | syscall.IXON | syscall.BRKINT | syscall.INPCK | syscall.ISTRIP
+	bruto.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	bruto.Cc[syscall.VMIN] = 1
+	bruto.Cc[syscall.VTIME] = 0
+	if err := gravarTermios(fd, bruto); err != nil {
+		return nil, err
+	}
+	return func() { gravarTermios(fd, original) }, nil
+}
+
+type tamanhoJanela struct {
+	linhas, colunas, x, y uint16
+}
+
+// tamanhoTerminal devolve o número de colunas e linhas do terminal
+func tamanhoTerminal(fd int) (int, int, error) {
+	var j tamanhoJanela
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&j)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+	return int(j.colunas), int(j.linhas), nil
+}
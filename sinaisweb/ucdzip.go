@@ -0,0 +1,73 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// nomeZipUCD é o arquivo em que a Unicode publica toda a UCD de uma versão
+const nomeZipUCD = "UCD.zip"
+
+var arquivoZipUCD = arquivoUCD{nomeZipUCD, nomeZipUCD}
+
+// arquivoDoZip lê um arquivo de dentro do zip e, ao ser fechado, fecha
+// também o zip
+type arquivoDoZip struct {
+	io.ReadCloser
+	zip *zip.ReadCloser
+}
+
+func (a *arquivoDoZip) Close() error {
+	a.ReadCloser.Close()
+	return a.zip.Close()
+}
+
+// acharNoZip procura o arquivo pelo caminho dentro do zip, como
+// emoji/emoji-data.txt, ou apenas pelo nome em qualquer diretório
+func acharNoZip(z *zip.Reader, caminho string) *zip.File {
+	var semelhante *zip.File
+	for _, f := range z.File {
+		if f.Name == caminho {
+			return f
+		}
+		if semelhante == nil && strings.HasSuffix(f.Name, "/"+caminho[strings.LastIndex(caminho, "/")+1:]) {
+			semelhante = f
+		}
+	}
+	return semelhante
+}
+
+// abrirDoZip abre o arquivo indicado por caminho dentro do UCD.zip
+func abrirDoZip(caminhoZip, caminho string) (io.ReadCloser, error) {
+	z, err := zip.OpenReader(caminhoZip)
+	if err != nil {
+		return nil, err
+	}
+	f := acharNoZip(&z.Reader, caminho)
+	if f == nil {
+		z.Close()
+		return nil, fmt.Errorf("%s: %s não está no arquivo: %w", caminhoZip, caminho, fs.ErrNotExist)
+	}
+	conteúdo, err := f.Open()
+	if err != nil {
+		z.Close()
+		return nil, err
+	}
+	return &arquivoDoZip{ReadCloser: conteúdo, zip: z}, nil
+}
+
+// validarZip confere se o zip tem um UnicodeData.txt válido
+func validarZip(caminhoZip string) string {
+	conteúdo, err := abrirDoZip(caminhoZip, "UnicodeData.txt")
+	if err != nil {
+		return err.Error()
+	}
+	defer conteúdo.Close()
+	if motivo := validarConteúdo("UnicodeData.txt", conteúdo); motivo != "" {
+		return "UnicodeData.txt: " + motivo
+	}
+	return ""
+}
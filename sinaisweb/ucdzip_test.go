@@ -0,0 +1,183 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// criarZipUCD grava um UCD.zip com os arquivos informados
+func criarZipUCD(t *testing.T, caminho string, arquivos map[string]string) {
+	t.Helper()
+	destino, err := os.Create(caminho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer destino.Close()
+	z := zip.NewWriter(destino)
+	for nome, conteúdo := range arquivos {
+		w, err := z.Create(nome)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, conteúdo)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var arquivosZipDeTeste = map[string]string{
+	"UnicodeData.txt":      linhas3Da43,
+	"Blocks.txt":           "# Blocks-15.1.0.txt\n0000..007F; Basic Latin\n",
+	"emoji/emoji-data.txt": "# emoji-data.txt\n1F600; Emoji\n",
+}
+
+func TestAbrirDoZip(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), nomeZipUCD)
+	criarZipUCD(t, caminho, arquivosZipDeTeste)
+	casos := []struct {
+		nome     string
+		esperado string
+	}{
+		{"UnicodeData.txt", linhas3Da43},
+		{"emoji/emoji-data.txt", arquivosZipDeTeste["emoji/emoji-data.txt"]},
+		{"emoji-data.txt", arquivosZipDeTeste["emoji/emoji-data.txt"]},
+	}
+	for _, caso := range casos {
+		conteúdo, err := abrirDoZip(caminho, caso.nome)
+		if err != nil {
+			t.Errorf("abrirDoZip(%q): %v", caso.nome, err)
+			continue
+		}
+		lido, _ := io.ReadAll(conteúdo)
+		conteúdo.Close()
+		if string(lido) != caso.esperado {
+			t.Errorf("abrirDoZip(%q)\nesperado: %q; recebido: %q", caso.nome, caso.esperado, lido)
+		}
+	}
+	if _, err := abrirDoZip(caminho, "Scripts.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("arquivo ausente do zip deveria dar fs.ErrNotExist; recebido: %v", err)
+	}
+}
+
+func TestValidarZip(t *testing.T) {
+	dir := t.TempDir()
+	válido := filepath.Join(dir, "válido.zip")
+	criarZipUCD(t, válido, arquivosZipDeTeste)
+	semUnicodeData := filepath.Join(dir, "sem.zip")
+	criarZipUCD(t, semUnicodeData, map[string]string{"Blocks.txt": "0000..007F; Basic Latin\n"})
+	html := filepath.Join(dir, "html.zip")
+	os.WriteFile(html, []byte("<html>erro</html>"), 0o644)
+	casos := []struct {
+		caminho string
+		válido  bool
+	}{
+		{válido, true},
+		{semUnicodeData, false},
+		{html, false},
+	}
+	for _, caso := range casos {
+		if motivo := validarZip(caso.caminho); (motivo == "") != caso.válido {
+			t.Errorf("validarZip(%s): %q", filepath.Base(caso.caminho), motivo)
+		}
+	}
+}
+
+func TestRepositórioVersãoComZip(t *testing.T) {
+	r := repositórioDados{diretório: t.TempDir()}.daVersão("15.1.0")
+	os.MkdirAll(r.diretório, 0o755)
+	criarZipUCD(t, r.caminho(nomeZipUCD), arquivosZipDeTeste)
+	if err := r.registrar(nomeZipUCD, "teste", validadores{}); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := r.lerManifesto()
+	if versão := m.Arquivos[nomeZipUCD].Versão; versão != "15.1.0" {
+		t.Errorf("versão do zip no manifesto\nesperado: %q; recebido: %q", "15.1.0", versão)
+	}
+	ucd, err := r.abrir("UnicodeData.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ucd.Close()
+	if linhas := carregar(ucd); len(linhas) != 7 {
+		t.Errorf("UnicodeData.txt do zip tem %d linhas; esperado: 7", len(linhas))
+	}
+	if _, err := os.Stat(r.caminho("UnicodeData.txt")); !os.IsNotExist(err) {
+		t.Errorf("o zip deveria ser o único arquivo da versão")
+	}
+}
+
+func TestRepositórioSemVersãoBaixaZip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pedidos := []string{}
+	dir := t.TempDir()
+	criarZipUCD(t, filepath.Join(dir, nomeZipUCD), arquivosZipDeTeste)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pedidos = append(pedidos, r.URL.Path)
+		http.ServeFile(w, r, filepath.Join(dir, nomeZipUCD))
+	}))
+	defer srv.Close()
+	r := repositórioDados{diretório: t.TempDir(),
+		download: opçõesDownload{Espelhos: []string{srv.URL}, Progresso: progressoSilencioso}}
+	for _, nome := range []string{"UnicodeData.txt", "Blocks.txt", "emoji-data.txt"} {
+		arquivo, err := r.abrir(nome)
+		if err != nil {
+			t.Fatalf("abrir(%s): %v", nome, err)
+		}
+		arquivo.Close()
+	}
+	if esperado := []string{"/UCD/latest/ucd/UCD.zip"}; !reflect.DeepEqual(pedidos, esperado) {
+		t.Errorf("downloads\nesperado: %q; recebido: %q", esperado, pedidos)
+	}
+	if _, err := os.Stat(r.caminho("UnicodeData.txt")); !os.IsNotExist(err) {
+		t.Errorf("o zip deveria ser o único arquivo de dados")
+	}
+}
+
+func TestCarregarUCD_zipEmUCDPath(t *testing.T) {
+	isolarConfiguração(t, "")
+	caminho := filepath.Join(t.TempDir(), nomeZipUCD)
+	criarZipUCD(t, caminho, arquivosZipDeTeste)
+	t.Setenv("UCD_PATH", caminho)
+	cfg, err := carregarConfiguração()
+	if err != nil {
+		t.Fatal(err)
+	}
+	linhas, err := carregarUCD(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(linhas) != 7 {
+		t.Errorf("carregarUCD com UCD_PATH=%s: %d linhas; esperado: 7", caminho, len(linhas))
+	}
+}
+
+func TestBaixarAtomicamente_zip(t *testing.T) {
+	dir := t.TempDir()
+	criarZipUCD(t, filepath.Join(dir, "servido.zip"), arquivosZipDeTeste)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/html/UCD.zip" {
+			w.Write([]byte("<html>manutenção</html>"))
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(dir, "servido.zip"))
+	}))
+	defer srv.Close()
+	if err := baixarAtomicamente(context.Background(), srv.URL+"/UCD.zip", filepath.Join(dir, nomeZipUCD), opçõesDownload{}); err != nil {
+		t.Errorf("zip válido: %v", err)
+	}
+	err := baixarAtomicamente(context.Background(), srv.URL+"/html/UCD.zip", filepath.Join(dir, "recusado.zip"), opçõesDownload{})
+	var conteúdo *ErroConteúdo
+	if !errors.As(err, &conteúdo) {
+		t.Errorf("página HTML no lugar do zip deveria dar ErroConteúdo; recebido: %v", err)
+	}
+}